@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kkettinger/go-tinysa"
+	"github.com/kkettinger/go-tinysa/internal/freq"
+)
+
+// bandNamePattern restricts band names to characters that need no escaping in metric labels.
+var bandNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// band is a frequency range swept by the exporter.
+type band struct {
+	name  string
+	start uint64
+	stop  uint64
+}
+
+// bandsFlag is a repeatable flag.Value for bands in the form `name=start:stop`, e.g. `ism433=433.05M:434.79M`.
+type bandsFlag []band
+
+func (b *bandsFlag) String() string {
+	parts := make([]string, 0, len(*b))
+	for _, band := range *b {
+		parts = append(parts, fmt.Sprintf("%s=%d:%d", band.name, band.start, band.stop))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (b *bandsFlag) Set(s string) error {
+	parsed, err := parseBand(s)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(*b, func(other band) bool { return other.name == parsed.name }) {
+		return fmt.Errorf("duplicate band %q", parsed.name)
+	}
+	*b = append(*b, parsed)
+	return nil
+}
+
+// parseBand parses a band in the form `name=start:stop`.
+func parseBand(s string) (band, error) {
+	name, rng, ok := strings.Cut(s, "=")
+	if !ok || !bandNamePattern.MatchString(name) {
+		return band{}, fmt.Errorf("invalid band %q, want name=start:stop", s)
+	}
+	startStr, stopStr, ok := strings.Cut(rng, ":")
+	if !ok {
+		return band{}, fmt.Errorf("invalid band %q, want name=start:stop", s)
+	}
+
+	start, err := freq.Parse(startStr)
+	if err != nil {
+		return band{}, err
+	}
+	stop, err := freq.Parse(stopStr)
+	if err != nil {
+		return band{}, err
+	}
+	if start >= stop {
+		return band{}, fmt.Errorf("invalid band %q, start must be below stop", s)
+	}
+
+	return band{name: name, start: start, stop: stop}, nil
+}
+
+// bandStats are the measurements of a single band sweep.
+type bandStats struct {
+	peakPower     float64 // Highest level in dBm
+	peakFrequency uint64  // Frequency of the highest level in Hz
+	channelPower  float64 // Power in the band, integrated over the points, in dBm
+	noiseFloor    float64 // Median level in dBm
+}
+
+// computeBandStats computes the measurements of a band sweep. data must not be empty. rbwHz is the resolution bandwidth
+// of the sweep, or 0 if it is selected automatically.
+//
+// Each point measures the power within the resolution bandwidth, so the channel power is the linear sum of all points
+// scaled by the ratio of point spacing and resolution bandwidth. This keeps it independent of the number of points.
+// The automatic resolution bandwidth follows the point spacing, so the ratio is 1 in that case.
+func computeBandStats(data []tinysa.TraceData, rbwHz uint64) bandStats {
+	stats := bandStats{peakPower: math.Inf(-1)}
+
+	var totalMilliwatts float64
+	levels := make([]float64, 0, len(data))
+	for _, d := range data {
+		if d.Value > stats.peakPower {
+			stats.peakPower, stats.peakFrequency = d.Value, d.Frequency
+		}
+		totalMilliwatts += math.Pow(10, d.Value/10)
+		levels = append(levels, d.Value)
+	}
+	if n := len(data); n > 1 && rbwHz > 0 {
+		spacing := float64(data[n-1].Frequency-data[0].Frequency) / float64(n-1)
+		totalMilliwatts *= spacing / float64(rbwHz)
+	}
+	stats.channelPower = 10 * math.Log10(totalMilliwatts)
+
+	slices.Sort(levels)
+	if n := len(levels); n%2 == 1 {
+		stats.noiseFloor = levels[n/2]
+	} else {
+		stats.noiseFloor = (levels[n/2-1] + levels[n/2]) / 2
+	}
+
+	return stats
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kkettinger/go-tinysa"
+)
+
+// bandResult is the last successful sweep of a band.
+type bandResult struct {
+	stats    bandStats
+	duration time.Duration
+}
+
+// exporter sweeps the configured bands and serves the results in the Prometheus text format. It implements
+// http.Handler.
+type exporter struct {
+	bands  []band
+	points uint
+	open   func() (*tinysa.Device, error)
+	logger *slog.Logger
+
+	// dev is only used by the collecting goroutine.
+	dev *tinysa.Device
+
+	mu          sync.Mutex
+	up          bool
+	connected   bool // A device was connected before, so the next connect is a reconnect
+	results     map[string]bandResult
+	sweepErrors map[string]uint64
+	battery     float64 // Battery voltage in V, 0 if unknown
+	timeouts    uint64
+	reconnects  uint64
+}
+
+// newExporter creates an exporter sweeping bands with the given number of points, on the device returned by open.
+func newExporter(bands []band, points uint, open func() (*tinysa.Device, error), logger *slog.Logger) *exporter {
+	return &exporter{
+		bands:       bands,
+		points:      points,
+		open:        open,
+		logger:      logger,
+		results:     make(map[string]bandResult),
+		sweepErrors: make(map[string]uint64),
+	}
+}
+
+// run collects all bands every interval until ctx is done, and closes the device afterwards.
+func (e *exporter) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer e.close()
+
+	for {
+		e.collect(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect connects the device if needed, and sweeps all bands once. If the connection is lost, the device is closed
+// and connected again by the next collect.
+func (e *exporter) collect(ctx context.Context) {
+	if e.dev == nil && !e.connect() {
+		return
+	}
+
+	// Without the resolution bandwidth, the channel power is computed as for the automatic setting.
+	rbw, err := e.dev.GetRBWContext(ctx)
+	if err != nil && e.handleError(ctx, "failed to read rbw", err, "") {
+		return
+	}
+
+	for _, b := range e.bands {
+		begin := time.Now()
+		data, err := e.dev.ScanRawContext(ctx, b.start, b.stop, e.points)
+		if err != nil {
+			if e.handleError(ctx, "sweep failed", err, b.name) {
+				return
+			}
+			continue
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		result := bandResult{stats: computeBandStats(data, rbw.Frequency), duration: time.Since(begin)}
+		e.mu.Lock()
+		e.results[b.name] = result
+		e.mu.Unlock()
+	}
+
+	mv, err := e.dev.GetBatteryVoltageContext(ctx)
+	if err != nil {
+		e.handleError(ctx, "failed to read battery voltage", err, "")
+		return
+	}
+	e.mu.Lock()
+	e.battery = float64(mv) / 1000
+	e.mu.Unlock()
+}
+
+// connect opens the device and reports whether it succeeded.
+func (e *exporter) connect() bool {
+	dev, err := e.open()
+	if err != nil {
+		e.logger.Warn("failed to connect device", "err", err)
+		return false
+	}
+	e.dev = dev
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.connected {
+		e.reconnects++
+	}
+	e.connected, e.up = true, true
+	e.logger.Info("device connected", "model", dev.Model(), "version", dev.Version())
+	return true
+}
+
+// handleError counts err, and closes the device if the connection seems lost. It reports whether the device was
+// closed. band is the band being swept, or empty.
+func (e *exporter) handleError(ctx context.Context, msg string, err error, band string) bool {
+	e.logger.Warn(msg, "band", band, "err", err)
+
+	e.mu.Lock()
+	if band != "" {
+		e.sweepErrors[band]++
+	}
+	timeout := errors.Is(err, tinysa.ErrCommandResponseTimeout)
+	if timeout {
+		e.timeouts++
+	}
+	e.mu.Unlock()
+
+	if timeout || ctx.Err() != nil {
+		return false
+	}
+	e.close()
+	return true
+}
+
+// close closes the device, if connected.
+func (e *exporter) close() {
+	if e.dev == nil {
+		return
+	}
+	_ = e.dev.Close()
+	e.dev = nil
+
+	e.mu.Lock()
+	e.up = false
+	e.mu.Unlock()
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format.
+func (e *exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.writeMetrics(w)
+}
+
+// writeMetrics writes all metrics in the Prometheus text format to w.
+func (e *exporter) writeMetrics(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	up := 0.0
+	if e.up {
+		up = 1
+	}
+	writeMetric(w, "tinysa_up", "Whether the device is connected.", "gauge", sample{value: up})
+
+	bandSamples := func(value func(r bandResult) float64) []sample {
+		var samples []sample
+		for _, b := range e.bands {
+			if r, ok := e.results[b.name]; ok {
+				samples = append(samples, sample{band: b.name, value: value(r)})
+			}
+		}
+		return samples
+	}
+	writeMetric(w, "tinysa_band_peak_power_dbm", "Highest level of the last band sweep.", "gauge",
+		bandSamples(func(r bandResult) float64 { return r.stats.peakPower })...)
+	writeMetric(w, "tinysa_band_peak_frequency_hz", "Frequency of the highest level of the last band sweep.", "gauge",
+		bandSamples(func(r bandResult) float64 { return float64(r.stats.peakFrequency) })...)
+	writeMetric(w, "tinysa_band_channel_power_dbm", "Power of the last band sweep, integrated over the band.", "gauge",
+		bandSamples(func(r bandResult) float64 { return r.stats.channelPower })...)
+	writeMetric(w, "tinysa_band_noise_floor_dbm", "Median level of the last band sweep.", "gauge",
+		bandSamples(func(r bandResult) float64 { return r.stats.noiseFloor })...)
+	writeMetric(w, "tinysa_band_sweep_duration_seconds", "Duration of the last band sweep.", "gauge",
+		bandSamples(func(r bandResult) float64 { return r.duration.Seconds() })...)
+
+	var errSamples []sample
+	for _, b := range e.bands {
+		errSamples = append(errSamples, sample{band: b.name, value: float64(e.sweepErrors[b.name])})
+	}
+	writeMetric(w, "tinysa_band_sweep_errors_total", "Number of failed band sweeps.", "counter", errSamples...)
+
+	if e.battery > 0 {
+		writeMetric(w, "tinysa_battery_voltage_volts", "Battery voltage of the device.", "gauge",
+			sample{value: e.battery})
+	}
+	writeMetric(w, "tinysa_command_timeouts_total", "Number of commands failed with a response timeout.", "counter",
+		sample{value: float64(e.timeouts)})
+	writeMetric(w, "tinysa_reconnects_total", "Number of reconnects after the connection was lost.", "counter",
+		sample{value: float64(e.reconnects)})
+}
+
+// sample is a single value of a metric, with an optional band label.
+type sample struct {
+	band  string
+	value float64
+}
+
+// writeMetric writes a metric with its help and type lines. Nothing is written without samples.
+func writeMetric(w io.Writer, name string, help string, typ string, samples ...sample) {
+	if len(samples) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	_, _ = fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+	for _, s := range samples {
+		labels := ""
+		if s.band != "" {
+			labels = fmt.Sprintf("{band=%q}", s.band)
+		}
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(s.value))
+	}
+}
+
+// formatValue formats a sample value, including the special values of the Prometheus text format.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,133 @@
+// Command tinysa-exporter periodically sweeps configured bands with a tinySA and exposes the results as Prometheus
+// metrics, e.g. for long-term RF occupancy monitoring.
+//
+// Usage:
+//
+//	tinysa-exporter [flags] --band name=start:stop [--band ...]
+//
+// Metrics are served at /metrics of the --listen address.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kkettinger/go-tinysa"
+)
+
+// Exit codes of the tool.
+const (
+	exitOK      = 0 // Exporter stopped by signal
+	exitFailure = 1 // Exporter failed
+	exitUsage   = 2 // Invalid command line
+)
+
+// openDevice connects to the device on the given port, or searches all ports if port is empty.
+var openDevice = func(port string, opts ...tinysa.DeviceOption) (*tinysa.Device, error) {
+	if port == "" {
+		return tinysa.FindDevice(opts...)
+	}
+	return tinysa.NewDevice(port, opts...)
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stderr))
+}
+
+// run starts the exporter with the command line args until ctx is done, and returns the exit code.
+func run(ctx context.Context, args []string, stderr io.Writer) int {
+	var (
+		port            string
+		listen          string
+		interval        time.Duration
+		points          uint
+		responseTimeout time.Duration
+		verbose         bool
+		bands           bandsFlag
+	)
+
+	fs := flag.NewFlagSet("tinysa-exporter", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&port, "port", "", "serial port of the device, searches all ports if empty")
+	fs.StringVar(&listen, "listen", ":9810", "address to serve the metrics on")
+	fs.DurationVar(&interval, "interval", time.Minute, "time between the start of two sweeps of all bands")
+	fs.UintVar(&points, "points", 290, "number of points per band sweep")
+	fs.DurationVar(&responseTimeout, "response-timeout", 2*time.Second, "timeout for a complete response")
+	fs.BoolVar(&verbose, "v", false, "log the device communication to stderr")
+	fs.Var(&bands, "band", "band to sweep as name=start:stop, e.g. ism433=433M:435M (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		_, _ = fmt.Fprintf(stderr, "tinysa-exporter: unexpected arguments %q\n", fs.Args())
+		return exitUsage
+	}
+	if len(bands) == 0 {
+		_, _ = fmt.Fprintln(stderr, "tinysa-exporter: at least one --band is required")
+		return exitUsage
+	}
+	if points < 2 || interval <= 0 {
+		_, _ = fmt.Fprintln(stderr, "tinysa-exporter: --points must be at least 2 and --interval positive")
+		return exitUsage
+	}
+
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: level}))
+
+	e := newExporter(bands, points, func() (*tinysa.Device, error) {
+		opts := []tinysa.DeviceOption{tinysa.WithResponseTimeout(responseTimeout)}
+		if verbose {
+			opts = append(opts, tinysa.WithLogger(logger))
+		}
+		return openDevice(port, opts...)
+	}, logger)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", e)
+	srv := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errs := make(chan error, 1)
+	go func() { errs <- srv.ListenAndServe() }()
+	logger.Info("serving metrics", "addr", listen, "bands", len(bands))
+
+	// The collector closes the device when it stops, so it is waited for before returning.
+	collectCtx, stopCollect := context.WithCancel(ctx)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		e.run(collectCtx, interval)
+	}()
+	defer func() {
+		stopCollect()
+		<-collected
+	}()
+
+	select {
+	case err := <-errs:
+		_, _ = fmt.Fprintf(stderr, "tinysa-exporter: %s\n", err.Error())
+		return exitFailure
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+		return exitOK
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kkettinger/go-tinysa"
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+// mutableTransport wraps a simulator and drops all writes while muted, so commands run into a response timeout.
+type mutableTransport struct {
+	*tinysatest.Simulator
+	muted atomic.Bool
+}
+
+func (m *mutableTransport) Write(p []byte) (int, error) {
+	if m.muted.Load() {
+		return len(p), nil
+	}
+	return m.Simulator.Write(p)
+}
+
+// newTestExporter creates an exporter for the given bands, connecting a new simulator on every connect. The returned
+// function returns the transport of the last connect.
+func newTestExporter(t *testing.T, bands ...band) (*exporter, func() *mutableTransport) {
+	t.Helper()
+
+	var last *mutableTransport
+	open := func() (*tinysa.Device, error) {
+		sim := tinysatest.New(tinysatest.ModelBasic)
+		sim.SetSignal(433920000, -30)
+		last = &mutableTransport{Simulator: sim}
+		return tinysa.NewDeviceFromTransport(last,
+			tinysa.WithReadTimeout(5*time.Millisecond), tinysa.WithResponseTimeout(20*time.Millisecond))
+	}
+
+	e := newExporter(bands, 101, open, slog.New(slog.DiscardHandler))
+	t.Cleanup(e.close)
+	return e, func() *mutableTransport { return last }
+}
+
+// metrics returns the metrics of the exporter in the text format.
+func metrics(e *exporter) string {
+	var buf bytes.Buffer
+	e.writeMetrics(&buf)
+	return buf.String()
+}
+
+func TestParseBand(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      band
+		shouldErr bool
+	}{
+		{name: "suffixes", input: "ism433=433.05M:434.79M", want: band{name: "ism433", start: 433050000, stop: 434790000}},
+		{name: "plain hz", input: "low=100:200", want: band{name: "low", start: 100, stop: 200}},
+		{name: "missing name", input: "=1M:2M", shouldErr: true},
+		{name: "invalid name", input: "a b=1M:2M", shouldErr: true},
+		{name: "missing stop", input: "a=1M", shouldErr: true},
+		{name: "start above stop", input: "a=2M:1M", shouldErr: true},
+		{name: "invalid frequency", input: "a=x:1M", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBand(tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("parseBand(%q) error = %v, shouldErr %v", tt.input, err, tt.shouldErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseBand(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBandsFlagDuplicate(t *testing.T) {
+	var bands bandsFlag
+	if err := bands.Set("a=1M:2M"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bands.Set("a=3M:4M"); err == nil {
+		t.Error("Set() of duplicate band succeeded, want error")
+	}
+}
+
+func TestComputeBandStats(t *testing.T) {
+	data := []tinysa.TraceData{
+		{Frequency: 100, Value: -90},
+		{Frequency: 200, Value: -30},
+		{Frequency: 300, Value: -30},
+		{Frequency: 400, Value: -80},
+	}
+	got := computeBandStats(data, 0)
+
+	if got.peakPower != -30 || got.peakFrequency != 200 {
+		t.Errorf("peak = %v dBm at %d Hz, want -30 dBm at 200 Hz", got.peakPower, got.peakFrequency)
+	}
+	if got.noiseFloor != -55 {
+		t.Errorf("noise floor = %v, want -55", got.noiseFloor)
+	}
+	if math.Abs(got.channelPower-(-26.99)) > 0.01 {
+		t.Errorf("channel power = %v, want about -26.99", got.channelPower)
+	}
+
+	// A resolution bandwidth of twice the point spacing counts every signal on two points.
+	if got = computeBandStats(data, 200); math.Abs(got.channelPower-(-30.0)) > 0.01 {
+		t.Errorf("channel power with rbw = %v, want about -30.0", got.channelPower)
+	}
+}
+
+func TestExporterMetrics(t *testing.T) {
+	e, _ := newTestExporter(t, band{name: "ism433", start: 433000000, stop: 435000000})
+	e.collect(context.Background())
+
+	out := metrics(e)
+	for _, want := range []string{
+		"# TYPE tinysa_up gauge\ntinysa_up 1\n",
+		"tinysa_band_peak_power_dbm{band=\"ism433\"} ",
+		"tinysa_band_peak_frequency_hz{band=\"ism433\"} ",
+		"tinysa_band_channel_power_dbm{band=\"ism433\"} ",
+		"tinysa_band_noise_floor_dbm{band=\"ism433\"} ",
+		"tinysa_band_sweep_duration_seconds{band=\"ism433\"} ",
+		"tinysa_band_sweep_errors_total{band=\"ism433\"} 0\n",
+		"tinysa_battery_voltage_volts ",
+		"tinysa_command_timeouts_total 0\n",
+		"tinysa_reconnects_total 0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, out)
+		}
+	}
+
+	peak := e.results["ism433"].stats.peakFrequency
+	if peak < 433900000 || peak > 433940000 {
+		t.Errorf("peak frequency = %d, want near 433920000", peak)
+	}
+}
+
+func TestExporterTimeout(t *testing.T) {
+	e, transport := newTestExporter(t, band{name: "a", start: 1000000, stop: 2000000})
+	e.collect(context.Background())
+
+	transport().muted.Store(true)
+	e.collect(context.Background())
+
+	out := metrics(e)
+	for _, want := range []string{
+		"tinysa_up 1\n",
+		"tinysa_command_timeouts_total 3\n",
+		"tinysa_band_sweep_errors_total{band=\"a\"} 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExporterReconnect(t *testing.T) {
+	e, transport := newTestExporter(t, band{name: "a", start: 1000000, stop: 2000000})
+	e.collect(context.Background())
+
+	_ = transport().Simulator.Close()
+	e.collect(context.Background())
+	if out := metrics(e); !strings.Contains(out, "tinysa_up 0\n") {
+		t.Errorf("metrics after connection loss do not contain tinysa_up 0:\n%s", out)
+	}
+
+	e.collect(context.Background())
+	out := metrics(e)
+	for _, want := range []string{"tinysa_up 1\n", "tinysa_reconnects_total 1\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no band", args: nil},
+		{name: "invalid band", args: []string{"--band", "x"}},
+		{name: "too few points", args: []string{"--band", "a=1M:2M", "--points", "1"}},
+		{name: "surplus argument", args: []string{"--band", "a=1M:2M", "extra"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			if code := run(context.Background(), tt.args, &stderr); code != exitUsage {
+				t.Errorf("run(%q) = %d, want %d", tt.args, code, exitUsage)
+			}
+		})
+	}
+}
+
+// closeTransport wraps a simulator and records whether it was closed.
+type closeTransport struct {
+	*tinysatest.Simulator
+	closed atomic.Bool
+}
+
+func (c *closeTransport) Close() error {
+	c.closed.Store(true)
+	return c.Simulator.Close()
+}
+
+func TestRunClosesDevice(t *testing.T) {
+	sim := tinysatest.New(tinysatest.ModelBasic)
+	transport := &closeTransport{Simulator: sim}
+	defer func(open func(string, ...tinysa.DeviceOption) (*tinysa.Device, error)) { openDevice = open }(openDevice)
+	openDevice = func(string, ...tinysa.DeviceOption) (*tinysa.Device, error) {
+		return tinysa.NewDeviceFromTransport(transport, tinysa.WithReadTimeout(5*time.Millisecond))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// Stop once the first band was swept.
+		for !slices.ContainsFunc(sim.Commands(), func(cmd string) bool { return strings.HasPrefix(cmd, "scanraw") }) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		cancel()
+	}()
+
+	var stderr bytes.Buffer
+	args := []string{"--listen", "127.0.0.1:0", "--interval", "1h", "--band", "a=433M:435M"}
+	if code := run(ctx, args, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, want %d: %s", code, exitOK, stderr.String())
+	}
+	if !transport.closed.Load() {
+		t.Error("device not closed when run() returned")
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kkettinger/go-tinysa"
+	"github.com/kkettinger/go-tinysa/internal/freq"
+	"golang.org/x/image/bmp"
+)
+
+// env holds the device and outputs of a command. The device is connected by connect, after the command has checked
+// its arguments, so usage errors are reported without a device attached.
+type env struct {
+	dev    *tinysa.Device
+	open   func() (*tinysa.Device, error)
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// connect opens the device, if not connected yet. Failures are returned as connectionError.
+func (e *env) connect() error {
+	if e.dev != nil {
+		return nil
+	}
+	dev, err := e.open()
+	if err != nil {
+		return connectionError{err: err}
+	}
+	e.dev = dev
+	return nil
+}
+
+// close closes the device, if connected.
+func (e *env) close() {
+	if e.dev != nil {
+		_ = e.dev.Close()
+	}
+}
+
+// command is a subcommand of the tool.
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   func(e *env, args []string) error
+}
+
+// commands lists all subcommands, in the order shown in the usage.
+var commands = []command{
+	{name: "info", usage: "", help: "show model, firmware and battery information", run: runInfo},
+	{name: "sweep", usage: "[--start f] [--stop f] [--points n]", help: "show or set the sweep range", run: runSweep},
+	{name: "trace", usage: "dump [--trace n] [--format csv|json]", help: "dump trace data", run: runTrace},
+	{name: "capture", usage: "[-o file.png|file.bmp]", help: "save a screenshot", run: runCapture},
+	{name: "marker", usage: "[id]", help: "show all or a single marker", run: runMarker},
+	{name: "raw", usage: "\"<cmd>\"", help: "send a raw command and print the response", run: runRaw},
+	{name: "preset", usage: "load|save <id>", help: "load or save a preset", run: runPreset},
+	{name: "shell", usage: "", help: "interactive shell with history and tab completion", run: runShell},
+}
+
+// findCommand returns the command with the given name.
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// newFlagSet returns a flag set for the arguments of a command, which reports errors as usageError.
+func newFlagSet(name string, e *env) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// parseFlags parses args and returns parsing errors and surplus arguments as usageError.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usageError{msg: err.Error()}
+	}
+	if fs.NArg() > 0 {
+		return newUsageError("unexpected arguments %q", fs.Args())
+	}
+	return nil
+}
+
+// frequencyFlag is a flag.Value for frequencies in Hz, with an optional k, M or G suffix (e.g. `433.92M`).
+type frequencyFlag struct {
+	value uint64
+	set   bool
+}
+
+func (f *frequencyFlag) String() string {
+	return strconv.FormatUint(f.value, 10)
+}
+
+func (f *frequencyFlag) Set(s string) error {
+	v, err := freq.Parse(s)
+	if err != nil {
+		return err
+	}
+	f.value, f.set = v, true
+	return nil
+}
+
+func runInfo(e *env, args []string) error {
+	if err := parseFlags(newFlagSet("info", e), args); err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	width, height := e.dev.ScreenResolution()
+	_, _ = fmt.Fprintf(e.stdout, "Model:            %s\n", e.dev.Model())
+	_, _ = fmt.Fprintf(e.stdout, "Version:          %s\n", e.dev.Version())
+	_, _ = fmt.Fprintf(e.stdout, "Hardware version: %s\n", e.dev.HardwareVersion())
+	_, _ = fmt.Fprintf(e.stdout, "Screen:           %dx%d\n", width, height)
+
+	vbat, err := e.dev.GetBatteryVoltage()
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(e.stdout, "Battery:          %d mV\n", vbat)
+
+	return nil
+}
+
+func runSweep(e *env, args []string) error {
+	var start, stop frequencyFlag
+	fs := newFlagSet("sweep", e)
+	fs.Var(&start, "start", "start frequency in Hz (k, M, G suffix allowed)")
+	fs.Var(&stop, "stop", "stop frequency in Hz (k, M, G suffix allowed)")
+	points := fs.Uint("points", 0, "number of sweep points")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if start.set && stop.set && start.value > stop.value {
+		return newUsageError("start frequency %d is above stop frequency %d", start.value, stop.value)
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	if start.set || stop.set || *points > 0 {
+		sweep, err := e.dev.GetSweep()
+		if err != nil {
+			return err
+		}
+		if start.set {
+			sweep.Start = start.value
+		}
+		if stop.set {
+			sweep.Stop = stop.value
+		}
+		if *points > 0 {
+			sweep.Points = *points
+		}
+		if sweep.Start > sweep.Stop {
+			return newUsageError("start frequency %d is above stop frequency %d", sweep.Start, sweep.Stop)
+		}
+		if err = e.dev.SetSweepStartStopWithPoints(sweep.Start, sweep.Stop, sweep.Points); err != nil {
+			return err
+		}
+	}
+
+	sweep, err := e.dev.GetSweep()
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(e.stdout, "start=%d stop=%d points=%d\n", sweep.Start, sweep.Stop, sweep.Points)
+
+	return nil
+}
+
+// traceDataJSON is the JSON representation of a trace data point.
+type traceDataJSON struct {
+	Trace     uint    `json:"trace"`
+	Point     uint    `json:"point"`
+	Frequency uint64  `json:"frequency"`
+	Value     float64 `json:"value"`
+}
+
+func runTrace(e *env, args []string) error {
+	if len(args) == 0 || args[0] != "dump" {
+		return newUsageError("missing subcommand dump")
+	}
+
+	fs := newFlagSet("trace dump", e)
+	traceID := fs.Uint("trace", 1, "trace id")
+	format := fs.String("format", "csv", "output format, csv or json")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return newUsageError("invalid format %q", *format)
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	data, err := e.dev.GetTraceData(*traceID)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		out := make([]traceDataJSON, len(data))
+		for i, d := range data {
+			out[i] = traceDataJSON(d)
+		}
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	_, _ = fmt.Fprintln(e.stdout, "point,frequency,value")
+	for _, d := range data {
+		_, _ = fmt.Fprintf(e.stdout, "%d,%d,%s\n", d.Point, d.Frequency, strconv.FormatFloat(d.Value, 'f', -1, 64))
+	}
+	return nil
+}
+
+func runCapture(e *env, args []string) error {
+	fs := newFlagSet("capture", e)
+	output := fs.String("o", "tinysa_capture.png", "output file, format is selected by extension (.png or .bmp)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var encode func(io.Writer, image.Image) error
+	switch strings.ToLower(filepath.Ext(*output)) {
+	case ".png":
+		encode = png.Encode
+	case ".bmp":
+		encode = bmp.Encode
+	default:
+		return newUsageError("unsupported image format %q", filepath.Ext(*output))
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	img, err := e.dev.Capture()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err = encode(f, img); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(e.stdout, "Screenshot saved to", *output)
+	return nil
+}
+
+func runMarker(e *env, args []string) error {
+	fs := newFlagSet("marker", e)
+	if err := fs.Parse(args); err != nil {
+		return usageError{msg: err.Error()}
+	}
+
+	if fs.NArg() > 1 {
+		return newUsageError("unexpected arguments %q", fs.Args()[1:])
+	}
+	var id uint64
+	if fs.NArg() == 1 {
+		var err error
+		if id, err = strconv.ParseUint(fs.Arg(0), 10, 0); err != nil {
+			return newUsageError("invalid marker id %q", fs.Arg(0))
+		}
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	var markers []tinysa.Marker
+	if fs.NArg() == 0 {
+		all, err := e.dev.GetMarkerAll()
+		if err != nil {
+			return err
+		}
+		markers = all
+	} else {
+		m, err := e.dev.GetMarker(uint(id))
+		if err != nil {
+			return err
+		}
+		markers = []tinysa.Marker{m}
+	}
+
+	for _, m := range markers {
+		_, _ = fmt.Fprintf(e.stdout, "marker=%d index=%d frequency=%d value=%s\n",
+			m.Marker, m.Index, m.Frequency, strconv.FormatFloat(m.Value, 'f', -1, 64))
+	}
+	return nil
+}
+
+func runRaw(e *env, args []string) error {
+	if len(args) == 0 {
+		return newUsageError("missing command")
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	res, err := e.dev.SendCommand(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if res != "" {
+		_, _ = fmt.Fprintln(e.stdout, res)
+	}
+	return nil
+}
+
+func runPreset(e *env, args []string) error {
+	if len(args) != 2 {
+		return newUsageError("expected action and preset id")
+	}
+	id, err := strconv.ParseUint(args[1], 10, 0)
+	if err != nil {
+		return newUsageError("invalid preset id %q", args[1])
+	}
+
+	if args[0] != "load" && args[0] != "save" {
+		return newUsageError("invalid action %q", args[0])
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	if args[0] == "load" {
+		return e.dev.LoadPreset(uint(id))
+	}
+	return e.dev.SavePreset(uint(id))
+}
@@ -0,0 +1,169 @@
+// Command tinysa controls a tinySA spectrum analyzer from the command line.
+//
+// Usage:
+//
+//	tinysa [flags] <command> [arguments]
+//
+// Run `tinysa help` for a list of commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/kkettinger/go-tinysa"
+)
+
+// Exit codes of the tool.
+const (
+	exitOK         = 0 // Command succeeded
+	exitFailure    = 1 // Command failed
+	exitUsage      = 2 // Invalid command line
+	exitConnection = 3 // No device found or connection failed
+)
+
+// usageError is returned for invalid command line arguments.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// newUsageError returns a usageError with a formatted message.
+func newUsageError(format string, args ...any) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// connectionError is returned if the device cannot be connected.
+type connectionError struct {
+	err error
+}
+
+func (e connectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e connectionError) Unwrap() error {
+	return e.err
+}
+
+// globalOptions holds the flags shared by all commands.
+type globalOptions struct {
+	port            string
+	baudRate        int
+	readTimeout     time.Duration
+	responseTimeout time.Duration
+	verbose         bool
+}
+
+// deviceOptions returns the library options selected by the flags.
+func (g globalOptions) deviceOptions(stderr io.Writer) []tinysa.DeviceOption {
+	opts := []tinysa.DeviceOption{
+		tinysa.WithBaudRate(g.baudRate),
+		tinysa.WithReadTimeout(g.readTimeout),
+		tinysa.WithResponseTimeout(g.responseTimeout),
+	}
+	if g.verbose {
+		opts = append(opts, tinysa.WithLogger(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))))
+	}
+	return opts
+}
+
+// openDevice connects to the device on the given port, or searches all ports if port is empty.
+var openDevice = func(port string, opts ...tinysa.DeviceOption) (*tinysa.Device, error) {
+	if port == "" {
+		return tinysa.FindDevice(opts...)
+	}
+	return tinysa.NewDevice(port, opts...)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit code.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	var g globalOptions
+
+	fs := flag.NewFlagSet("tinysa", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&g.port, "port", "", "serial port of the device, searches all ports if empty")
+	fs.IntVar(&g.baudRate, "baud", 115200, "serial port baud rate")
+	fs.DurationVar(&g.readTimeout, "read-timeout", 500*time.Millisecond, "timeout of a single read")
+	fs.DurationVar(&g.responseTimeout, "response-timeout", 2*time.Second, "timeout for a complete response")
+	fs.BoolVar(&g.verbose, "v", false, "log the device communication to stderr")
+	fs.Usage = func() { printUsage(stderr, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if fs.NArg() == 0 {
+		printUsage(stderr, fs)
+		return exitUsage
+	}
+
+	name := fs.Arg(0)
+	if name == "help" {
+		printUsage(stdout, fs)
+		return exitOK
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "tinysa: unknown command %q, run `tinysa help` for usage\n", name)
+		return exitUsage
+	}
+
+	e := &env{
+		open:   func() (*tinysa.Device, error) { return openDevice(g.port, g.deviceOptions(stderr)...) },
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	defer e.close()
+
+	if err := cmd.run(e, fs.Args()[1:]); err != nil {
+		var connErr connectionError
+		if errors.As(err, &connErr) {
+			_, _ = fmt.Fprintf(stderr, "tinysa: failed to connect: %s\n", err.Error())
+			return exitConnection
+		}
+		_, _ = fmt.Fprintf(stderr, "tinysa %s: %s\n", cmd.name, err.Error())
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			_, _ = fmt.Fprintf(stderr, "usage: tinysa %s %s\n", cmd.name, cmd.usage)
+			return exitUsage
+		}
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+// printUsage prints the usage of the tool, its global flags and all commands.
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	_, _ = fmt.Fprintln(w, "Usage: tinysa [flags] <command> [arguments]")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.help)
+		_, _ = fmt.Fprintf(w, "  %-8s usage: tinysa %s %s\n", "", cmd.name, cmd.usage)
+	}
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Flags:")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkettinger/go-tinysa"
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+// useSimulator lets openDevice connect to a simulator of the given model for the duration of the test.
+func useSimulator(t *testing.T, model tinysatest.Model) *tinysatest.Simulator {
+	t.Helper()
+
+	sim := tinysatest.New(model)
+	orig := openDevice
+	openDevice = func(_ string, opts ...tinysa.DeviceOption) (*tinysa.Device, error) {
+		opts = append(opts, tinysa.WithReadTimeout(10*time.Millisecond))
+		return tinysa.NewDeviceFromTransport(sim, opts...)
+	}
+	t.Cleanup(func() { openDevice = orig })
+
+	return sim
+}
+
+// runArgs runs the tool with the given arguments and returns exit code, stdout and stderr.
+func runArgs(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		code    int
+		stdout  string
+		command string
+	}{
+		{name: "info", args: []string{"info"}, code: exitOK, stdout: "Model:            tinySA4"},
+		{name: "sweep get", args: []string{"sweep"}, code: exitOK, stdout: "start=0 stop=800000000 points=450"},
+		{
+			name:    "sweep set",
+			args:    []string{"sweep", "--start", "433M", "--stop", "435MHz", "--points", "101"},
+			code:    exitOK,
+			stdout:  "start=433000000 stop=435000000 points=101",
+			command: "sweep 433000000 435000000 101",
+		},
+		{name: "sweep start above stop", args: []string{"sweep", "--start", "1G"}, code: exitUsage},
+		{name: "sweep invalid frequency", args: []string{"sweep", "--start", "fast"}, code: exitUsage},
+		{name: "trace dump csv", args: []string{"trace", "dump"}, code: exitOK, stdout: "point,frequency,value\n0,0,"},
+		{name: "trace dump invalid format", args: []string{"trace", "dump", "--format", "xml"}, code: exitUsage},
+		{name: "trace without dump", args: []string{"trace"}, code: exitUsage},
+		{name: "marker all", args: []string{"marker"}, code: exitOK, stdout: "marker=1 index="},
+		{name: "marker single", args: []string{"marker", "1"}, code: exitOK, stdout: "marker=1 index="},
+		{name: "marker invalid id", args: []string{"marker", "one"}, code: exitUsage},
+		{name: "raw", args: []string{"raw", "vbat"}, code: exitOK, stdout: "4191 mV\n", command: "vbat"},
+		{name: "raw with arguments", args: []string{"raw", "sweep start 100000000"}, code: exitOK, command: "sweep start 100000000"},
+		{name: "raw missing command", args: []string{"raw"}, code: exitUsage},
+		{name: "preset load", args: []string{"preset", "load", "2"}, code: exitOK, command: "load 2"},
+		{name: "preset save", args: []string{"preset", "save", "3"}, code: exitOK, command: "save 3"},
+		{name: "preset invalid action", args: []string{"preset", "delete", "3"}, code: exitUsage},
+		{name: "unknown command", args: []string{"calibrate"}, code: exitUsage},
+		{name: "no command", args: nil, code: exitUsage},
+		{name: "help", args: []string{"help"}, code: exitOK, stdout: "Commands:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := useSimulator(t, tinysatest.ModelUltra)
+
+			code, stdout, stderr := runArgs(tt.args...)
+			if code != tt.code {
+				t.Fatalf("exit code = %d, want %d, stderr: %s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stdout, tt.stdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout, tt.stdout)
+			}
+			if tt.command != "" && !slices.Contains(sim.Commands(), tt.command) {
+				t.Errorf("command %q not sent, got %q", tt.command, sim.Commands())
+			}
+		})
+	}
+}
+
+func TestRunTraceDumpJSON(t *testing.T) {
+	useSimulator(t, tinysatest.ModelBasic)
+
+	code, stdout, stderr := runArgs("trace", "dump", "--format", "json", "--trace", "1")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr: %s", code, stderr)
+	}
+
+	var data []traceDataJSON
+	if err := json.Unmarshal([]byte(stdout), &data); err != nil {
+		t.Fatalf("invalid json output: %s", err.Error())
+	}
+	if len(data) != 290 || data[0].Trace != 1 || data[289].Frequency != 350000000 {
+		t.Errorf("unexpected json output, %d points", len(data))
+	}
+}
+
+func TestRunCapture(t *testing.T) {
+	useSimulator(t, tinysatest.ModelUltra)
+	output := filepath.Join(t.TempDir(), "screen.png")
+
+	code, _, stderr := runArgs("capture", "-o", output)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr: %s", code, stderr)
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 480 || img.Bounds().Dy() != 320 {
+		t.Errorf("image bounds = %v, want 480x320", img.Bounds())
+	}
+
+	useSimulator(t, tinysatest.ModelUltra)
+	if code, _, _ = runArgs("capture", "-o", "screen.gif"); code != exitUsage {
+		t.Errorf("exit code for unsupported format = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestRunConnectionFailure(t *testing.T) {
+	orig := openDevice
+	openDevice = func(string, ...tinysa.DeviceOption) (*tinysa.Device, error) {
+		return nil, errors.New("no device found")
+	}
+	t.Cleanup(func() { openDevice = orig })
+
+	if code, _, _ := runArgs("info"); code != exitConnection {
+		t.Errorf("exit code = %d, want %d", code, exitConnection)
+	}
+
+	// Usage errors are reported without connecting the device.
+	for _, args := range [][]string{
+		{"info", "extra"},
+		{"sweep", "--start", "2G", "--stop", "1G"},
+		{"trace", "dump", "--format", "xml"},
+		{"capture", "-o", "screen.gif"},
+		{"marker", "one"},
+		{"raw"},
+		{"preset", "delete", "3"},
+		{"shell", "extra"},
+	} {
+		code, _, stderr := runArgs(args...)
+		if code != exitUsage || !strings.Contains(stderr, "usage: tinysa "+args[0]) {
+			t.Errorf("%q: exit code = %d, want %d with usage, stderr %q", args, code, exitUsage, stderr)
+		}
+	}
+}
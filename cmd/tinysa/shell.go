@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kkettinger/go-tinysa"
+	"golang.org/x/term"
+)
+
+// shellPrompt is the prompt of the interactive shell, the same as the one of the firmware.
+const shellPrompt = "ch> "
+
+// shellCompletions maps firmware commands to the subcommands and arguments offered by tab completion.
+var shellCompletions = map[string][]string{
+	"attenuate":   {"auto"},
+	"calc":        append([]string{"off"}, tinysa.TraceCalcOptions()...),
+	"capture":     nil,
+	"deviceid":    nil,
+	"frequencies": nil,
+	"freq":        nil,
+	"level":       nil,
+	"lna":         {"on", "off"},
+	"load":        nil,
+	"marker":      {"1", "2", "3", "4", "on", "off", "peak", "delta", "tracking", "trace"},
+	"menu":        nil,
+	"mode":        {"low", "high", "input", "output"},
+	"modulation":  {"off", "AM", "NFM", "WFM", "extern", "freq"},
+	"output":      {"on", "off"},
+	"pause":       nil,
+	"rbw":         {"auto"},
+	"reset":       {"dfu"},
+	"resume":      nil,
+	"save":        nil,
+	"scan":        nil,
+	"scanraw":     nil,
+	"sd_delete":   nil,
+	"sd_list":     nil,
+	"sd_read":     nil,
+	"spur":        {"on", "off", "auto"},
+	"status":      nil,
+	"sweep":       {"start", "stop", "center", "span", "cw", "normal", "precise", "fast", "noise"},
+	"sweeptime":   nil,
+	"trace":       {"1", "2", "3", "4", "on", "off", "value", "dBm", "dBmV", "dBuV", "V", "W", "RAW", "scale", "reflevel"},
+	"trigger":     {"auto", "normal", "single", "up", "down"},
+	"vbat":        nil,
+	"vbat_offset": nil,
+	"version":     nil,
+}
+
+// shellBinaryCommands lists the firmware commands with a binary response.
+var shellBinaryCommands = []string{"capture", "scanraw", "sd_read"}
+
+// shellMetaCommands are handled by the shell itself instead of being sent to the device.
+var shellMetaCommands = []string{".help", ".hex", ".exit"}
+
+// shell is an interactive session sending each line as command to the device.
+type shell struct {
+	dev *tinysa.Device
+	out io.Writer
+	hex bool // Show binary responses as hex dump
+}
+
+func runShell(e *env, args []string) error {
+	if err := parseFlags(newFlagSet("shell", e), args); err != nil {
+		return err
+	}
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	sh := &shell{dev: e.dev, out: e.stdout}
+
+	// Without a terminal (e.g. piped input), read plain lines without history and completion.
+	f, ok := e.stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) { // #nosec G115
+		return sh.runLines(e.stdin)
+	}
+
+	state, err := term.MakeRaw(int(f.Fd())) // #nosec G115
+	if err != nil {
+		return err
+	}
+	defer func() { _ = term.Restore(int(f.Fd()), state) }() // #nosec G115
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{f, e.stdout}, shellPrompt)
+	t.AutoCompleteCallback = complete
+	sh.out = t
+
+	_, _ = fmt.Fprintln(t, "Connected to", e.dev.Model(), e.dev.Version()+", type .help for help")
+	for {
+		line, err := t.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if sh.execute(line) {
+			return nil
+		}
+	}
+}
+
+// runLines executes every line read from r, until r is exhausted or `.exit` is read.
+func (sh *shell) runLines(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if sh.execute(scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// execute runs a single input line and reports whether the shell should exit.
+func (sh *shell) execute(line string) bool {
+	line = strings.TrimSpace(line)
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch fields[0] {
+	case ".exit", "exit", "quit":
+		return true
+	case ".help":
+		sh.printHelp()
+		return false
+	case ".hex":
+		switch {
+		case len(fields) == 1:
+			sh.hex = !sh.hex
+		case fields[1] == "on" || fields[1] == "off":
+			sh.hex = fields[1] == "on"
+		default:
+			_, _ = fmt.Fprintln(sh.out, "usage: .hex [on|off]")
+			return false
+		}
+		if sh.hex {
+			_, _ = fmt.Fprintln(sh.out, "hex dump on")
+		} else {
+			_, _ = fmt.Fprintln(sh.out, "hex dump off")
+		}
+		return false
+	}
+
+	if slices.Contains(shellBinaryCommands, fields[0]) {
+		data, err := sh.dev.SendCommandBinary(line)
+		if err != nil {
+			_, _ = fmt.Fprintln(sh.out, "error:", err.Error())
+			return false
+		}
+		sh.printBinary(data)
+		return false
+	}
+
+	res, err := sh.dev.SendCommand(line)
+	if err != nil {
+		_, _ = fmt.Fprintln(sh.out, "error:", err.Error())
+		return false
+	}
+	sh.printText(res)
+	return false
+}
+
+// printText prints a text response. Multi-line responses are aligned in columns.
+func (sh *shell) printText(res string) {
+	if res == "" {
+		return
+	}
+
+	lines := strings.Split(res, "\r\n")
+	if len(lines) == 1 {
+		_, _ = fmt.Fprintln(sh.out, res)
+		return
+	}
+
+	w := tabwriter.NewWriter(sh.out, 0, 0, 2, ' ', 0)
+	for _, line := range lines {
+		_, _ = fmt.Fprintln(w, strings.Join(strings.Fields(line), "\t"))
+	}
+	_ = w.Flush()
+}
+
+// printBinary prints a binary response as hex dump, or only its length if hex dump display is disabled.
+func (sh *shell) printBinary(data []byte) {
+	if !sh.hex {
+		_, _ = fmt.Fprintf(sh.out, "%d bytes binary response, use .hex to show\n", len(data))
+		return
+	}
+	_, _ = fmt.Fprint(sh.out, hex.Dump(data))
+}
+
+// printHelp prints the meta commands of the shell.
+func (sh *shell) printHelp() {
+	_, _ = fmt.Fprintln(sh.out, "Commands are sent to the device as typed, use tab to complete them.")
+	_, _ = fmt.Fprintln(sh.out, "  .hex [on|off]  toggle hex dump of binary responses (e.g. capture)")
+	_, _ = fmt.Fprintln(sh.out, "  .help          show this help")
+	_, _ = fmt.Fprintln(sh.out, "  .exit          leave the shell (or Ctrl-D)")
+}
+
+// complete is the term.Terminal AutoCompleteCallback. It completes the command name in the first word and the known
+// subcommands in the second word, up to the longest common prefix of all candidates.
+func complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' || pos != len(line) {
+		return "", 0, false
+	}
+
+	fields := strings.Fields(line)
+	if strings.HasSuffix(line, " ") || len(fields) == 0 {
+		fields = append(fields, "")
+	}
+
+	var candidates []string
+	switch len(fields) {
+	case 1:
+		candidates = slices.Concat(shellMetaCommands, slices.Sorted(maps.Keys(shellCompletions)))
+	case 2:
+		candidates = shellCompletions[fields[0]]
+	default:
+		return "", 0, false
+	}
+
+	word := fields[len(fields)-1]
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := matches[0]
+	if len(matches) == 1 {
+		completion += " "
+	} else {
+		for _, m := range matches[1:] {
+			for !strings.HasPrefix(m, completion) {
+				completion = completion[:len(completion)-1]
+			}
+		}
+	}
+	if len(completion) <= len(word) {
+		return "", 0, false
+	}
+
+	newLine := line[:len(line)-len(word)] + completion
+	return newLine, len(newLine), true
+}
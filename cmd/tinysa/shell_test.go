@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+func TestShell(t *testing.T) {
+	sim := useSimulator(t, tinysatest.ModelUltra)
+
+	input := strings.Join([]string{
+		"vbat",
+		"",
+		"marker",
+		"sweep start 100M",
+		"capture",
+		".hex on",
+		"scanraw 100000000 200000000 2",
+		".hex",
+		".exit",
+		"vbat_offset",
+	}, "\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"shell"}, strings.NewReader(input), &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code = %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+
+	for _, want := range []string{
+		"4191 mV\n",
+		"307200 bytes binary response, use .hex to show\n",
+		"hex dump on\n",
+		"00000000  ",
+		"hex dump off\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	// Commands after .exit must not be sent.
+	for _, cmd := range sim.Commands() {
+		if cmd == "vbat_offset" {
+			t.Error("command after .exit was sent")
+		}
+	}
+}
+
+func TestShellPrintText(t *testing.T) {
+	var out bytes.Buffer
+	sh := &shell{out: &out}
+
+	sh.printText("1 216 522167037 -9.08e+01\r\n2 5 100000000 -1.00e+02")
+	want := "1  216  522167037  -9.08e+01\n2  5    100000000  -1.00e+02\n"
+	if out.String() != want {
+		t.Errorf("printText() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		key  rune
+		want string
+		ok   bool
+	}{
+		{name: "unique command", line: "vbat_o", key: '\t', want: "vbat_offset ", ok: true},
+		{name: "common prefix", line: "sw", key: '\t', want: "sweep", ok: true},
+		{name: "ambiguous without progress", line: "sweep", key: '\t'},
+		{name: "subcommand", line: "sweep ce", key: '\t', want: "sweep center ", ok: true},
+		{name: "subcommand prefix", line: "calc aver", key: '\t'},
+		{name: "trace calculation", line: "calc q", key: '\t', want: "calc quasi ", ok: true},
+		{name: "meta command", line: ".e", key: '\t', want: ".exit ", ok: true},
+		{name: "unknown command", line: "xyz", key: '\t'},
+		{name: "third word", line: "sweep start 1", key: '\t'},
+		{name: "other key", line: "sw", key: 'a'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, pos, ok := complete(tt.line, len(tt.line), tt.key)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("complete(%q) = %q, %v, want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+			if ok && pos != len(got) {
+				t.Errorf("complete(%q) position = %d, want %d", tt.line, pos, len(got))
+			}
+		})
+	}
+}
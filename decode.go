@@ -0,0 +1,158 @@
+package tinysa
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// bmpFileHeaderLen is the length of the BMP file header preceding the DIB header.
+	bmpFileHeaderLen = 14
+
+	// bmpCompressionBitfields marks a BMP with explicit color masks.
+	bmpCompressionBitfields = 3
+)
+
+// DecodeBMP decodes a 16-bit RGB565 BMP screenshot, as saved by the device to its SD card, into an image.Image.
+// Other BMP formats are not supported, use golang.org/x/image/bmp for those.
+func DecodeBMP(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bmp: %w", err)
+	}
+
+	if len(data) < bmpFileHeaderLen+40 || data[0] != 'B' || data[1] != 'M' {
+		return nil, fmt.Errorf("not a bmp file")
+	}
+
+	pixelOffset := int(binary.LittleEndian.Uint32(data[10:]))
+	dib := data[bmpFileHeaderLen:]
+	dibLen := int(binary.LittleEndian.Uint32(dib[0:]))
+	width := int(int32(binary.LittleEndian.Uint32(dib[4:])))  // #nosec G115
+	height := int(int32(binary.LittleEndian.Uint32(dib[8:]))) // #nosec G115
+	bpp := binary.LittleEndian.Uint16(dib[14:])
+	compression := binary.LittleEndian.Uint32(dib[16:])
+
+	if dibLen < 40 || pixelOffset < bmpFileHeaderLen+dibLen {
+		return nil, fmt.Errorf("invalid bmp header")
+	}
+	if bpp != 16 || compression != bmpCompressionBitfields {
+		return nil, fmt.Errorf("unsupported bmp format, %d bits per pixel with compression %d", bpp, compression)
+	}
+
+	// The color masks follow the 40 byte info header, either as part of a larger header or separately.
+	if len(dib) < 52 {
+		return nil, fmt.Errorf("bmp color masks missing")
+	}
+	masks := [3]uint32{
+		binary.LittleEndian.Uint32(dib[40:]),
+		binary.LittleEndian.Uint32(dib[44:]),
+		binary.LittleEndian.Uint32(dib[48:]),
+	}
+	if masks != [3]uint32{0xF800, 0x07E0, 0x001F} {
+		return nil, fmt.Errorf("unsupported bmp color masks %#x", masks)
+	}
+
+	// A positive height means rows are stored bottom-up.
+	bottomUp := height > 0
+	if !bottomUp {
+		height = -height
+	}
+	if width <= 0 || height == 0 {
+		return nil, fmt.Errorf("invalid bmp size %dx%d", width, height)
+	}
+
+	// Rows are padded to a multiple of 4 bytes. The size is checked against the pixel data first, so a forged header
+	// can neither overflow the size computation nor allocate an image larger than the file.
+	available := len(data) - pixelOffset
+	if available < 0 || width > available/2 {
+		return nil, fmt.Errorf("bmp pixel data truncated, %dx%d pixels in %d bytes", width, height, max(available, 0))
+	}
+	stride := (width*2 + 3) &^ 3
+	if height > available/stride {
+		return nil, fmt.Errorf("bmp pixel data truncated, expected %d bytes, got %d", stride*height, available)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for row := range height {
+		y := row
+		if bottomUp {
+			y = height - 1 - row
+		}
+		line := data[pixelOffset+row*stride:]
+		for x := range width {
+			pixel := binary.LittleEndian.Uint16(line[x*2:])
+			img.SetRGBA(x, y, convertRGB565PixelToRGBA(pixel))
+		}
+	}
+
+	return img, nil
+}
+
+// DecodeCSV decodes a trace dump, as saved by the device to its SD card, into trace data. Each line contains the
+// frequency in Hz followed by the values of the saved traces. The file does not record which traces were saved, so
+// the value columns are numbered as trace 1, 2, ... in order of appearance. The result is ordered by trace, then point.
+//
+// Example file: `100000000, -9.08e+01, -8.70e+01\r\n100200000, -9.12e+01, -8.72e+01`
+func DecodeCSV(r io.Reader) ([]TraceData, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	var columns [][]TraceData
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		freq, err := strconv.ParseUint(strings.TrimSpace(record[0]), 10, 64)
+		if err != nil {
+			// Skip an optional header line.
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid frequency %q: %s", line, record[0], err.Error())
+		}
+
+		if columns == nil {
+			if len(record) < 2 {
+				return nil, fmt.Errorf("line %d: expected at least 2 fields, got %d", line, len(record))
+			}
+			columns = make([][]TraceData, len(record)-1)
+		}
+
+		for i, field := range record[1:] {
+			value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q: %s", line, field, err.Error())
+			}
+			columns[i] = append(columns[i], TraceData{
+				Trace:     uint(i + 1), // #nosec G115
+				Point:     uint(len(columns[i])),
+				Frequency: freq,
+				Value:     value,
+			})
+		}
+	}
+
+	if columns == nil {
+		return nil, fmt.Errorf("no trace data in csv")
+	}
+
+	var data []TraceData
+	for _, column := range columns {
+		data = append(data, column...)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,182 @@
+package tinysa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestBMP builds a 16-bit RGB565 bitfields BMP with the given (signed) height and rows in file order.
+func newTestBMP(width int32, height int32, masks [3]uint32, bpp uint16, rows ...[]uint16) []byte {
+	var pixels bytes.Buffer
+	for _, row := range rows {
+		for _, p := range row {
+			_ = binary.Write(&pixels, binary.LittleEndian, p)
+		}
+		for pixels.Len()%4 != 0 {
+			pixels.WriteByte(0)
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("BM")
+	_ = binary.Write(&b, binary.LittleEndian, []uint32{uint32(70 + pixels.Len()), 0, 70}) // #nosec G115
+	_ = binary.Write(&b, binary.LittleEndian, struct {
+		Size, Width, Height int32
+		Planes, BPP         uint16
+		Compression, Image  uint32
+		XRes, YRes          int32
+		Colors, Important   uint32
+		Masks               [4]uint32
+	}{56, width, height, 1, bpp, 3, uint32(pixels.Len()), 0, 0, 0, 0, [4]uint32{masks[0], masks[1], masks[2], 0}}) // #nosec G115
+	b.Write(pixels.Bytes())
+
+	return b.Bytes()
+}
+
+func TestDecodeBMP(t *testing.T) {
+	red, green, blue := uint16(0xF800), uint16(0x07E0), uint16(0x001F)
+	masks := [3]uint32{0xF800, 0x07E0, 0x001F}
+
+	tests := []struct {
+		name      string
+		input     []byte
+		want      [][]color.RGBA
+		shouldErr bool
+	}{
+		{
+			name:  "bottom-up with padding",
+			input: newTestBMP(3, 2, masks, 16, []uint16{blue, blue, blue}, []uint16{red, green, blue}),
+			want: [][]color.RGBA{
+				{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}},
+				{{0, 0, 255, 255}, {0, 0, 255, 255}, {0, 0, 255, 255}},
+			},
+		},
+		{
+			name:  "top-down",
+			input: newTestBMP(2, -2, masks, 16, []uint16{red, red}, []uint16{green, green}),
+			want: [][]color.RGBA{
+				{{255, 0, 0, 255}, {255, 0, 0, 255}},
+				{{0, 255, 0, 255}, {0, 255, 0, 255}},
+			},
+		},
+		{name: "rgb555 masks", input: newTestBMP(1, 1, [3]uint32{0x7C00, 0x03E0, 0x001F}, 16, []uint16{0}), shouldErr: true},
+		{name: "24 bit", input: newTestBMP(1, 1, masks, 24, []uint16{0, 0}), shouldErr: true},
+		{name: "truncated", input: newTestBMP(2, 2, masks, 16, []uint16{red, red}), shouldErr: true},
+		{name: "huge height", input: newTestBMP(2, 1<<30, masks, 16, []uint16{red, red}), shouldErr: true},
+		{name: "huge negative height", input: newTestBMP(2, -1<<31, masks, 16, []uint16{red, red}), shouldErr: true},
+		{name: "huge width", input: newTestBMP(1<<31-1, 1<<31-1, masks, 16, []uint16{red, red}), shouldErr: true},
+		{name: "overflowing size", input: newTestBMP(1<<31-1, -1<<31, masks, 16, []uint16{red, red}), shouldErr: true},
+		{name: "not a bmp", input: []byte("PNG"), shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := DecodeBMP(bytes.NewReader(tt.input))
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("DecodeBMP() error = %v, wantErr = %v", err, tt.shouldErr)
+			}
+			if tt.shouldErr {
+				return
+			}
+			if img.Bounds().Dy() != len(tt.want) || img.Bounds().Dx() != len(tt.want[0]) {
+				t.Fatalf("DecodeBMP() bounds = %v, want %dx%d", img.Bounds(), len(tt.want[0]), len(tt.want))
+			}
+			for y, row := range tt.want {
+				for x, want := range row {
+					if got := img.At(x, y); got != want {
+						t.Errorf("pixel at (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeBMPCompareWithCapture(t *testing.T) {
+	width, height := 480, 320
+	bin, err := os.ReadFile("testdata/capture_480x320.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := convertBinCaptureToImage(bin, width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open("testdata/screenshot_480x320.bmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := DecodeBMP(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("bounds = %v, want %v", got.Bounds(), want.Bounds())
+	}
+	for y := range height {
+		for x := range width {
+			if got.At(x, y) != want.At(x, y) {
+				t.Fatalf("pixel mismatch at (%d,%d): bmp=%v, capture=%v", x, y, got.At(x, y), want.At(x, y))
+			}
+		}
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      []TraceData
+		shouldErr bool
+	}{
+		{
+			name:  "single trace",
+			input: "100000000, -9.08e+01\r\n100200000, -9.12e+01\r\n",
+			want: []TraceData{
+				{Trace: 1, Point: 0, Frequency: 100000000, Value: -90.8},
+				{Trace: 1, Point: 1, Frequency: 100200000, Value: -91.2},
+			},
+		},
+		{
+			name:  "two traces",
+			input: "100000000, -90.8, -87.0\r\n100200000, -91.2, -87.2\r\n",
+			want: []TraceData{
+				{Trace: 1, Point: 0, Frequency: 100000000, Value: -90.8},
+				{Trace: 1, Point: 1, Frequency: 100200000, Value: -91.2},
+				{Trace: 2, Point: 0, Frequency: 100000000, Value: -87.0},
+				{Trace: 2, Point: 1, Frequency: 100200000, Value: -87.2},
+			},
+		},
+		{
+			name:  "header line",
+			input: "frequency, trace1\n433920000, -30.5\n",
+			want:  []TraceData{{Trace: 1, Point: 0, Frequency: 433920000, Value: -30.5}},
+		},
+		{name: "empty file", input: "", shouldErr: true},
+		{name: "frequency only", input: "100000000\r\n", shouldErr: true},
+		{name: "invalid value", input: "100000000, low\r\n", shouldErr: true},
+		{name: "invalid frequency", input: "100000000, -90\r\nhigh, -90\r\n", shouldErr: true},
+		{name: "inconsistent columns", input: "100000000, -90\r\n100200000, -90, -80\r\n", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("DecodeCSV(%q) error = %v, wantErr = %v", tt.input, err, tt.shouldErr)
+			}
+			if !tt.shouldErr && !slices.Equal(got, tt.want) {
+				t.Errorf("DecodeCSV(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
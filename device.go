@@ -2,7 +2,7 @@
 package tinysa
 
 import (
-	"go.bug.st/serial"
+	"context"
 	"log/slog"
 	"sync"
 	"time"
@@ -10,16 +10,46 @@ import (
 
 // Device represents a connected device instance with associated configuration and state.
 type Device struct {
-	port            serial.Port   // Serial port used for communication
+	port            Transport     // Transport (e.g. serial port) used for communication
 	mutex           sync.Mutex    // Mutex to ensure thread-safe access to the device
 	model           Model         // Device model (basic or ultra)
 	version         string        // Firmware version of the device
 	hwVersion       string        // Hardware version of the device
 	width           int           // Screen width in pixels
 	height          int           // Screen height in pixels
+	levelOffset     float64       // Offset in dB to convert raw scan values into dBm
+	rbwSteps        []uint64      // Supported resolution bandwidths in Hz
+	maxAtten        uint          // Maximum input attenuation in dB
+	sdCard          bool          // Device has a SD card slot
 	logger          *slog.Logger  // Optional logger for debugging and tracing
 	readTimeout     time.Duration // Timeout for reading from the device
 	responseTimeout time.Duration // Timeout for waiting for a response from the device
+
+	// Signal generator limits per output mode
+	generatorLimits map[GeneratorMode]GeneratorLimits
+
+	// Parsed firmware version, zero if the version could not be parsed
+	firmwareVersion FirmwareVersion
+
+	// Capabilities checked against model and firmware version
+	capabilities capabilityTable
+
+	// infoMutex guards portInfo, the device id and the input mode, so they can be read while a command is running
+	infoMutex sync.Mutex
+
+	// Serial port the device is connected on, only the name is set for ports without USB details and empty for
+	// devices created from a transport
+	portInfo PortInfo
+
+	// Device id last read or set, only valid if hasDeviceID is set
+	deviceID    uint
+	hasDeviceID bool
+
+	// Input mode last set with SetInputMode
+	inputMode InputMode
+
+	// Auto reconnect state, nil if auto reconnect is disabled
+	reconnect *reconnectState
 }
 
 // Close closes the open device.
@@ -45,6 +75,28 @@ func (d *Device) HardwareVersion() string {
 	return d.hwVersion
 }
 
+// PortInfo returns the serial port the device is connected on, including USB vendor id, product id and serial number
+// if available. It is empty for devices created by NewDeviceFromTransport.
+func (d *Device) PortInfo() PortInfo {
+	d.infoMutex.Lock()
+	defer d.infoMutex.Unlock()
+	return d.portInfo
+}
+
+// cachedDeviceID returns the device id last read with GetDeviceID or set with SetDeviceID, without sending a command.
+func (d *Device) cachedDeviceID() (uint, bool) {
+	d.infoMutex.Lock()
+	defer d.infoMutex.Unlock()
+	return d.deviceID, d.hasDeviceID
+}
+
+// setCachedDeviceID records the device id after it was read or set.
+func (d *Device) setCachedDeviceID(id uint) {
+	d.infoMutex.Lock()
+	defer d.infoMutex.Unlock()
+	d.deviceID, d.hasDeviceID = id, true
+}
+
 // ScreenResolution returns the screen width and height in pixels for the detected device model.
 func (d *Device) ScreenResolution() (width, height int) {
 	return d.width, d.height
@@ -52,36 +104,70 @@ func (d *Device) ScreenResolution() (width, height int) {
 
 // SendCommand sends a command to the device and returns the parsed response as string.
 func (d *Device) SendCommand(cmd string) (string, error) {
-	return d.sendCommand(cmd)
+	return d.SendCommandContext(context.Background(), cmd)
+}
+
+// SendCommandContext is like SendCommand but uses ctx to cancel the command.
+func (d *Device) SendCommandContext(ctx context.Context, cmd string) (string, error) {
+	return d.sendCommand(ctx, cmd)
 }
 
 // SendCommandBinary sends a command to the device and returns the parsed response as []byte.
 func (d *Device) SendCommandBinary(cmd string) ([]byte, error) {
-	return d.sendCommandBinary(cmd)
+	return d.SendCommandBinaryContext(context.Background(), cmd)
+}
+
+// SendCommandBinaryContext is like SendCommandBinary but uses ctx to cancel the command.
+func (d *Device) SendCommandBinaryContext(ctx context.Context, cmd string) ([]byte, error) {
+	return d.sendCommandBinary(ctx, cmd)
 }
 
 // sendCommand is the internal method for requesting commands and returning a string response.
-func (d *Device) sendCommand(cmd string) (string, error) {
+func (d *Device) sendCommand(ctx context.Context, cmd string) (string, error) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	res, err := sendCommand(d.logger, d.port, cmd, d.responseTimeout)
+	res, err := d.send(ctx, cmd)
 	if err != nil {
 		return "", err
 	}
 
-	return res, nil
+	return string(res), nil
+}
+
+// sendSetCommand is the internal method for commands that return no response on success, e.g. setters. Error
+// messages of the firmware are returned as *CommandError.
+func (d *Device) sendSetCommand(ctx context.Context, cmd string) error {
+	res, err := d.sendCommand(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	if err = parseCommandErrorResponse(cmd, res); err != nil {
+		d.logger.Error("command failed", "cmd", cmd, "response", res)
+		return err
+	}
+	return nil
 }
 
 // sendCommandBinary is the internal method for requesting commands and returning a binary response.
-func (d *Device) sendCommandBinary(cmd string) ([]byte, error) {
+func (d *Device) sendCommandBinary(ctx context.Context, cmd string) ([]byte, error) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	res, err := sendCommandBinary(d.logger, d.port, cmd, d.responseTimeout)
+	res, err := d.send(ctx, cmd)
 	if err != nil {
 		return []byte{}, err
 	}
 
 	return res, nil
 }
+
+// send sends a command on the port, reconnecting the device if the port was lost and auto reconnect is enabled.
+// The caller must hold the mutex.
+func (d *Device) send(ctx context.Context, cmd string) ([]byte, error) {
+	if d.reconnect == nil {
+		return sendCommandBinary(ctx, d.logger, d.port, cmd, d.responseTimeout)
+	}
+	return d.sendReconnecting(ctx, cmd)
+}
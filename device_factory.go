@@ -1,10 +1,21 @@
 package tinysa
 
 import (
+	"context"
 	"fmt"
-	"go.bug.st/serial"
+	"slices"
+	"strings"
+	"sync"
 )
 
+// probeWorkers is the maximum number of ports probed in parallel by FindDevice and FindDevices.
+const probeWorkers = 4
+
+// usbIDs are the USB vendor and product ids of the virtual serial ports of tinySA devices.
+var usbIDs = []struct{ vid, pid string }{
+	{vid: "0483", pid: "5740"}, // STMicroelectronics virtual COM port, used by the STM32 based models
+}
+
 // NewDevice creates a *Device from the specified port name.
 func NewDevice(portName string, opts ...DeviceOption) (*Device, error) {
 	options := defaultDeviceOptions()
@@ -20,24 +31,78 @@ func NewDevice(portName string, opts ...DeviceOption) (*Device, error) {
 
 	logger.Debug("initializing new device", "options", options)
 
+	device, err := newDeviceFromPort(context.Background(), lookupPort(logger, portName), options)
+	if err != nil {
+		logger.Error("failed to create device", "port", portName, "err", err)
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// newDeviceFromPort opens the given serial port and creates a *Device, with auto reconnect if enabled. ctx cancels
+// probing the device.
+func newDeviceFromPort(ctx context.Context, info PortInfo, options deviceOptions) (*Device, error) {
+	logger := options.logger
+
 	// open serial port
-	mode := &serial.Mode{BaudRate: options.baudrate}
-	logger.Debug("opening port", "port", portName, "baudrate", options.baudrate)
-	port, err := serial.Open(portName, mode)
+	logger.Debug("opening port", "port", info.Name, "baudrate", options.baudrate)
+	port, err := openPort(info.Name, options.baudrate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open port %s: %s", info.Name, err.Error())
+	}
+
+	device, err := newDeviceFromTransport(ctx, port, options)
 	if err != nil {
-		logger.Error("failed to open port", "err", err)
-		return nil, fmt.Errorf("failed to open port %s: %s", portName, err.Error())
+		_ = port.Close()
+		return nil, err
+	}
+	device.portInfo = info
+
+	if options.reconnectTimeout > 0 {
+		device.enableAutoReconnect(options)
+	}
+
+	return device, nil
+}
+
+// NewDeviceFromTransport creates a *Device from an already opened Transport, e.g. a TCP serial bridge or an
+// in-memory fake. The device is probed and its model detected just like with NewDevice. The baud rate and auto
+// reconnect options are ignored, since they only apply to serial ports. On error the transport is not closed.
+func NewDeviceFromTransport(transport Transport, opts ...DeviceOption) (*Device, error) {
+	options := defaultDeviceOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.logger == nil {
+		options.logger = newNoopLogger()
+	}
+
+	options.logger.Debug("initializing new device from transport", "options", options)
+
+	return newDeviceFromTransport(context.Background(), transport, options)
+}
+
+// newDeviceFromTransport sets up recording and the read timeout, probes the device on the given transport and creates a *Device.
+// ctx cancels probing the device.
+func newDeviceFromTransport(ctx context.Context, port Transport, options deviceOptions) (*Device, error) {
+	logger := options.logger
+
+	if options.recorder != nil {
+		logger.Debug("recording session")
+		port = newRecordingTransport(port, options.recorder, logger)
 	}
 
 	// set read timeout
-	if err = port.SetReadTimeout(options.readTimeout); err != nil {
+	if err := port.SetReadTimeout(options.readTimeout); err != nil {
 		logger.Error("failed to set read timeout", "err", err)
 		return nil, fmt.Errorf("failed to set read timeout: %s", err.Error())
 	}
 
 	// probe device
 	logger.Debug("probing device", "port", port)
-	pr, err := probeDevice(logger, port, options.responseTimeout)
+	pr, err := probeDevice(ctx, logger, port, options.responseTimeout)
 	if err != nil {
 		logger.Error("failed to probe device", "err", err)
 		return nil, fmt.Errorf("failed to probe device: %s", err.Error())
@@ -46,8 +111,44 @@ func NewDevice(portName string, opts ...DeviceOption) (*Device, error) {
 	return createDeviceFromProbe(logger, port, pr, options)
 }
 
-// FindDevice iterates over all serial ports and creates a *Device from the first valid tinySA device found.
+// FindDevice probes the serial ports of tinySA devices in parallel and returns the first device found, in the order
+// of the port list. Only ports with the USB ids of a tinySA are probed, unless the platform reports no USB details.
+// WithModel and WithMinFirmwareVersion restrict the devices found.
 func FindDevice(opts ...DeviceOption) (*Device, error) {
+	return FindDeviceContext(context.Background(), opts...)
+}
+
+// FindDeviceContext is like FindDevice but uses ctx to cancel probing the ports.
+func FindDeviceContext(ctx context.Context, opts ...DeviceOption) (*Device, error) {
+	devices, err := findDevices(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("no device found")
+	}
+
+	for _, device := range devices[1:] {
+		_ = device.Close()
+	}
+	return devices[0], nil
+}
+
+// FindDevices probes the serial ports of tinySA devices in parallel and returns all devices found, in the order of
+// the port list. Ports without a tinySA are skipped silently, so the result is empty if no device is connected.
+// Ports are selected and devices filtered like by FindDevice.
+func FindDevices(opts ...DeviceOption) ([]*Device, error) {
+	return FindDevicesContext(context.Background(), opts...)
+}
+
+// FindDevicesContext is like FindDevices but uses ctx to cancel probing the ports. If ctx is done before all ports
+// are probed, the devices found so far are closed and the context error is returned.
+func FindDevicesContext(ctx context.Context, opts ...DeviceOption) ([]*Device, error) {
+	return findDevices(ctx, opts)
+}
+
+// findDevices lists the serial ports, and probes the candidate ports in parallel.
+func findDevices(ctx context.Context, opts []DeviceOption) ([]*Device, error) {
 	options := defaultDeviceOptions()
 	for _, opt := range opts {
 		opt(&options)
@@ -59,26 +160,105 @@ func FindDevice(opts ...DeviceOption) (*Device, error) {
 
 	logger := options.logger
 
-	logger.Debug("finding device", "options", options)
+	logger.Debug("finding devices", "options", options)
+
+	var minVersion FirmwareVersion
+	if options.minVersion != "" {
+		v, err := ParseFirmwareVersion(options.minVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum firmware version: %s", err.Error())
+		}
+		minVersion = v
+	}
 
 	// list serial ports
-	ports, err := serial.GetPortsList()
+	ports, err := listPorts()
 	if err != nil {
 		logger.Error("failed to list serial ports", "err", err)
 		return nil, fmt.Errorf("failed to list serial ports: %s", err.Error())
 	}
 	logger.Debug("list serial ports", "ports", ports)
 
-	// try to create a device from each one
-	for _, portName := range ports {
-		logger.Debug("trying to create device", "port", portName)
-		device, err := NewDevice(portName, opts...)
-		if err != nil {
-			logger.Error("failed to create device, skipping", "port", portName, "err", err)
-			continue
+	ports = candidatePorts(ports)
+	logger.Debug("probing candidate ports", "ports", ports)
+
+	// probe the ports in parallel, keeping the results in port order
+	results := make([]*Device, len(ports))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(probeWorkers, len(ports)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				device, err := newDeviceFromPort(ctx, ports[i], options)
+				if err != nil {
+					logger.Debug("no device on port", "port", ports[i].Name, "err", err)
+					continue
+				}
+				if err = matchDevice(device, options.model, minVersion); err != nil {
+					logger.Info("skipping device", "port", ports[i].Name, "reason", err)
+					_ = device.Close()
+					continue
+				}
+				logger.Info("found device", "port", ports[i].Name, "model", device.model, "version", device.version)
+				results[i] = device
+			}
+		}()
+	}
+	for i := range ports {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		for _, device := range results {
+			if device != nil {
+				_ = device.Close()
+			}
 		}
-		return device, nil
+		return nil, err
+	}
+
+	return slices.DeleteFunc(results, func(d *Device) bool { return d == nil }), nil
+}
+
+// candidatePorts returns the ports with the USB ids of a tinySA. If no port has USB details, e.g. because the
+// platform does not support reading them, all ports are returned.
+func candidatePorts(ports []PortInfo) []PortInfo {
+	if !slices.ContainsFunc(ports, func(p PortInfo) bool { return p.IsUSB }) {
+		return ports
 	}
 
-	return nil, fmt.Errorf("no device found")
+	var candidates []PortInfo
+	for _, p := range ports {
+		for _, id := range usbIDs {
+			if p.IsUSB && strings.EqualFold(p.VID, id.vid) && strings.EqualFold(p.PID, id.pid) {
+				candidates = append(candidates, p)
+				break
+			}
+		}
+	}
+	return candidates
+}
+
+// matchDevice returns an error if the device is not of the given model, or its firmware is older than minVersion.
+// Empty values match all devices.
+func matchDevice(device *Device, model Model, minVersion FirmwareVersion) error {
+	if model != "" && device.model != model {
+		return fmt.Errorf("model %s, want %s", device.model, model)
+	}
+	if !minVersion.IsZero() {
+		if device.firmwareVersion.IsZero() {
+			return fmt.Errorf("unknown firmware version %q", device.version)
+		}
+		if !device.firmwareVersion.AtLeast(minVersion) {
+			return fmt.Errorf("firmware version %s is too old", device.version)
+		}
+	}
+	return nil
 }
@@ -11,13 +11,41 @@ const (
 
 // deviceModel holds metadata for a specific device model.
 type deviceModel struct {
-	model  Model
-	width  int
-	height int
+	model       Model
+	width       int
+	height      int
+	levelOffset float64                           // Offset in dB to convert `scanraw` values (raw/32) into dBm
+	rbwSteps    []uint64                          // Supported resolution bandwidths in Hz
+	maxAtten    uint                              // Maximum input attenuation in dB
+	sdCard      bool                              // Device has a SD card slot
+	generator   map[GeneratorMode]GeneratorLimits // Signal generator limits per output mode
 }
 
 // deviceModels maps model names to their corresponding deviceModel configurations.
 var deviceModels = map[string]deviceModel{
-	"tinySA":  {ModelBasic, 320, 280},
-	"tinySA4": {ModelUltra, 480, 320},
+	"tinySA": {
+		model:       ModelBasic,
+		width:       320,
+		height:      280,
+		levelOffset: 128,
+		rbwSteps:    []uint64{3e3, 10e3, 30e3, 100e3, 300e3, 600e3},
+		maxAtten:    31,
+		generator: map[GeneratorMode]GeneratorLimits{
+			GeneratorLow:  {MinFrequency: 100e3, MaxFrequency: 350e6, MinLevel: -76, MaxLevel: -6},
+			GeneratorHigh: {MinFrequency: 240e6, MaxFrequency: 960e6, MinLevel: -38, MaxLevel: 13},
+		},
+	},
+	"tinySA4": {
+		model:       ModelUltra,
+		width:       480,
+		height:      320,
+		levelOffset: 174,
+		rbwSteps:    []uint64{200, 1e3, 3e3, 10e3, 30e3, 100e3, 300e3, 600e3, 850e3},
+		maxAtten:    31,
+		sdCard:      true,
+		generator: map[GeneratorMode]GeneratorLimits{
+			GeneratorLow:  {MinFrequency: 100e3, MaxFrequency: 800e6, MinLevel: -115, MaxLevel: -19},
+			GeneratorHigh: {MinFrequency: 240e6, MaxFrequency: 4400e6, MinLevel: -38, MaxLevel: 13},
+		},
+	},
 }
@@ -1,6 +1,7 @@
 package tinysa
 
 import (
+	"io"
 	"log/slog"
 	"time"
 )
@@ -16,6 +17,18 @@ type deviceOptions struct {
 
 	// responseTimeout is the maximum time to wait for the full response.
 	responseTimeout time.Duration
+
+	// recorder receives a session recording of all sent and received bytes, if set.
+	recorder io.Writer
+
+	// reconnectTimeout is the maximum time to wait for a lost device to reappear, 0 disables auto reconnect.
+	reconnectTimeout time.Duration
+
+	// model restricts the devices found by FindDevice and FindDevices to a model, if set.
+	model Model
+
+	// minVersion restricts the devices found by FindDevice and FindDevices to a minimum firmware version, if set.
+	minVersion string
 }
 
 // defaultDeviceOptions returns a deviceOptions struct initialized with default values.
@@ -58,3 +71,36 @@ func WithResponseTimeout(timeout time.Duration) DeviceOption {
 		opts.responseTimeout = timeout
 	}
 }
+
+// WithRecorder records every sent command and received byte with timestamps to w. The recorded session can be played
+// back with NewReplayTransport.
+func WithRecorder(w io.Writer) DeviceOption {
+	return func(opts *deviceOptions) {
+		opts.recorder = w
+	}
+}
+
+// WithAutoReconnect reconnects the device if the serial port is lost, e.g. after a reset or a dropped USB cable. All
+// serial ports are searched for the same device, identified by USB serial number and device id, for up to timeout.
+// After reconnecting, the last sweep settings are restored and the failed command is sent again. Only applies to
+// devices opened by NewDevice or FindDevice.
+func WithAutoReconnect(timeout time.Duration) DeviceOption {
+	return func(opts *deviceOptions) {
+		opts.reconnectTimeout = timeout
+	}
+}
+
+// WithModel restricts FindDevice and FindDevices to devices of the given model.
+func WithModel(model Model) DeviceOption {
+	return func(opts *deviceOptions) {
+		opts.model = model
+	}
+}
+
+// WithMinFirmwareVersion restricts FindDevice and FindDevices to devices with at least the given firmware version,
+// e.g. `1.4-150`. Versions are compared like FirmwareVersion.Compare.
+func WithMinFirmwareVersion(version string) DeviceOption {
+	return func(opts *deviceOptions) {
+		opts.minVersion = version
+	}
+}
@@ -1,13 +1,12 @@
 package tinysa
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"regexp"
 	"sync"
 	"time"
-
-	"go.bug.st/serial"
 )
 
 // probeResult contains the parsed response of the `version` command, which is used to detect the tinySA model.
@@ -17,15 +16,19 @@ type probeResult struct {
 	hwVersion string
 }
 
-// probeDevice tries to detect a tinySA device on the given port, returning a probeResult.
-func probeDevice(logger *slog.Logger, port serial.Port, responseTimeout time.Duration) (probeResult, error) {
+// probeDevice tries to detect a tinySA device on the given port, returning a probeResult. Probing stops with the
+// context error once ctx is done.
+func probeDevice(ctx context.Context, logger *slog.Logger, port Transport, responseTimeout time.Duration) (probeResult, error) {
 	logger.Debug("probing device")
 
 	// We try multiple times to detect the tinySA, because directly after boot we find some malformed output.
 	// This helps us detect the tinySA reliably and also clears the input buffer for further commands.
 	i := 0
 	for i < 3 {
-		response, _ := sendCommand(logger, port, "version", responseTimeout)
+		response, _ := sendCommand(ctx, logger, port, "version", responseTimeout)
+		if err := ctx.Err(); err != nil {
+			return probeResult{}, err
+		}
 
 		if pr, err := parseVersionResponse(response); err == nil {
 			logger.Info("found valid device", "probe_result", pr)
@@ -42,7 +45,7 @@ func probeDevice(logger *slog.Logger, port serial.Port, responseTimeout time.Dur
 
 // parseVersionResponse matches the response of the `version` command and returns a probeResult.
 func parseVersionResponse(response string) (probeResult, error) {
-	var re = regexp.MustCompile(`^(tinySA\w+)_v?(\S+)?\s*HW Version:V(.*?)\s*$`)
+	var re = regexp.MustCompile(`^(tinySA\w*?)_v?(\S+)?\s*HW Version:V(.*?)\s*$`)
 
 	matches := re.FindStringSubmatch(response)
 	if len(matches) != 4 {
@@ -61,13 +64,18 @@ func parseVersionResponse(response string) (probeResult, error) {
 }
 
 // createDeviceFromProbe creates a new *Device from a probeResult.
-func createDeviceFromProbe(logger *slog.Logger, port serial.Port, pr probeResult, opts deviceOptions) (*Device, error) {
+func createDeviceFromProbe(logger *slog.Logger, port Transport, pr probeResult, opts deviceOptions) (*Device, error) {
 	cfg, ok := deviceModels[pr.model]
 	if !ok {
 		logger.Error("unknown model", "model", pr.model)
 		return nil, fmt.Errorf("unknown model %s", pr.model)
 	}
 
+	fwVersion, err := ParseFirmwareVersion(pr.version)
+	if err != nil {
+		logger.Warn("unknown firmware version format, capabilities are only checked by model", "version", pr.version)
+	}
+
 	return &Device{
 		port:            port,
 		mutex:           sync.Mutex{},
@@ -76,6 +84,13 @@ func createDeviceFromProbe(logger *slog.Logger, port serial.Port, pr probeResult
 		hwVersion:       pr.hwVersion,
 		width:           cfg.width,
 		height:          cfg.height,
+		levelOffset:     cfg.levelOffset,
+		rbwSteps:        cfg.rbwSteps,
+		maxAtten:        cfg.maxAtten,
+		sdCard:          cfg.sdCard,
+		generatorLimits: cfg.generator,
+		firmwareVersion: fwVersion,
+		capabilities:    capabilities,
 		logger:          logger,
 		readTimeout:     opts.readTimeout,
 		responseTimeout: opts.responseTimeout,
@@ -29,6 +29,16 @@ func TestMatchProbeResponse(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name:  "normal response basic",
+			input: "tinySA_v1.4-175-g1419ca3\r\nHW Version:V0.3.1",
+			want: probeResult{
+				model:     "tinySA",
+				version:   "1.4-175-g1419ca3",
+				hwVersion: "0.3.1",
+			},
+			expectError: false,
+		},
 		{
 			name:  "response with custom flashed firmware (no version)",
 			input: "tinySA4_\r\nHW Version:V0.4.5.1 ",
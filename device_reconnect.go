@@ -0,0 +1,203 @@
+package tinysa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// reconnectPollInterval is the time between two searches for a lost device.
+const reconnectPollInterval = 500 * time.Millisecond
+
+// reconnectState holds the identity of a device, used to find it again after the port was lost, and the settings
+// restored after reconnecting.
+type reconnectState struct {
+	options      deviceOptions
+	serialNumber string // USB serial number, empty if unknown
+	sweep        *Sweep // Last sweep settings, nil if unknown
+	sweepMode    string // Last sweep mode set (e.g. `precise`), empty if never set
+}
+
+// enableAutoReconnect records the identity and sweep settings of the device, and enables reconnecting it if the port
+// is lost. The device id is read once here and kept up to date by SetDeviceID.
+func (d *Device) enableAutoReconnect(options deviceOptions) {
+	r := &reconnectState{options: options, serialNumber: d.portInfo.SerialNumber}
+
+	if _, err := d.GetDeviceID(); err != nil {
+		d.logger.Warn("failed to read device id, device is identified by serial number only", "err", err)
+	}
+
+	if sweep, err := d.GetSweep(); err == nil {
+		r.sweep = &sweep
+	} else {
+		d.logger.Warn("failed to read sweep, sweep is not restored until set", "err", err)
+	}
+
+	d.logger.Info("auto reconnect enabled", "port", d.portInfo.Name, "serial_number", r.serialNumber)
+	d.reconnect = r
+}
+
+// sendReconnecting sends a command. If the port was lost, the device is reconnected and the command is sent again,
+// except for `reset`, which drops the connection on purpose; the device is reconnected by the next command then.
+// The caller must hold the mutex.
+func (d *Device) sendReconnecting(ctx context.Context, cmd string) ([]byte, error) {
+	res, err := sendCommandBinary(ctx, d.logger, d.port, cmd, d.responseTimeout)
+
+	var transportErr *transportError
+	if errors.As(err, &transportErr) && !isCommand(cmd+commandTerminator, "reset") {
+		if err = d.reconnectDevice(ctx); err != nil {
+			return nil, err
+		}
+		res, err = sendCommandBinary(ctx, d.logger, d.port, cmd, d.responseTimeout)
+	}
+
+	if err == nil {
+		d.trackSweep(ctx, cmd)
+	}
+	return res, err
+}
+
+// trackSweep records the sweep settings after a successful `sweep` command, so they can be restored after
+// reconnecting. The caller must hold the mutex.
+func (d *Device) trackSweep(ctx context.Context, cmd string) {
+	fields := strings.Fields(cmd)
+	if len(fields) < 2 || fields[0] != "sweep" {
+		return
+	}
+
+	switch fields[1] {
+	case sweepModeNormal, sweepModePrecise, sweepModeFast, sweepModeNoise:
+		d.reconnect.sweepMode = fields[1]
+		return
+	}
+
+	res, err := sendCommand(ctx, d.logger, d.port, "sweep", d.responseTimeout)
+	if err != nil {
+		d.logger.Warn("failed to read sweep after change", "err", err)
+		return
+	}
+	sweep, err := parseSweepResponse(res)
+	if err != nil {
+		d.logger.Warn("failed to parse sweep after change", "err", err)
+		return
+	}
+	d.reconnect.sweep = &sweep
+}
+
+// reconnectDevice searches the tinySA ports for the lost device until it is found or the reconnect timeout expired,
+// and restores the sweep settings. Probing is cancelled at the deadline, so the timeout is not overrun by silent
+// ports. The caller must hold the mutex.
+func (d *Device) reconnectDevice(ctx context.Context) error {
+	r := d.reconnect
+	d.logger.Warn("connection lost, reconnecting", "port", d.portInfo.Name, "timeout", r.options.reconnectTimeout)
+	_ = d.port.Close()
+
+	deadline := time.Now().Add(r.options.reconnectTimeout)
+	findCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	for {
+		dev, info, err := d.findLostDevice(findCtx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			d.port, d.version, d.hwVersion = dev.port, dev.version, dev.hwVersion
+			d.infoMutex.Lock()
+			d.portInfo = info
+			d.infoMutex.Unlock()
+			d.firmwareVersion = dev.firmwareVersion
+			d.logger.Info("device reconnected", "port", info.Name)
+			d.restoreSettings(ctx)
+			return nil
+		}
+		d.logger.Debug("lost device not found", "err", err)
+
+		if time.Now().Add(reconnectPollInterval).After(deadline) {
+			return fmt.Errorf("%w: device not found within %s", ErrReconnectFailed, r.options.reconnectTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(reconnectPollInterval):
+		}
+	}
+}
+
+// findLostDevice probes the tinySA ports matching the USB serial number of the lost device, and returns the first
+// device of the same model and device id together with its port. If the session is recorded, the recorder of the
+// lost device is reused for the new port, so the recording continues without a second header. Probing stops once ctx
+// is done.
+func (d *Device) findLostDevice(ctx context.Context) (*Device, PortInfo, error) {
+	r := d.reconnect
+
+	ports, err := listPorts()
+	if err != nil {
+		return nil, PortInfo{}, fmt.Errorf("failed to list serial ports: %s", err.Error())
+	}
+	ports = candidatePorts(ports)
+
+	for _, p := range ports {
+		if r.serialNumber != "" && p.SerialNumber != r.serialNumber {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, PortInfo{}, err
+		}
+
+		port, err := openPort(p.Name, r.options.baudrate)
+		if err != nil {
+			continue
+		}
+
+		transport, options := port, r.options
+		if rec, ok := d.port.(*recordingTransport); ok {
+			rec.Transport = port
+			transport, options.recorder = rec, nil
+		}
+
+		dev, err := newDeviceFromTransport(ctx, transport, options)
+		if err != nil {
+			_ = port.Close()
+			continue
+		}
+		if dev.model != d.model {
+			_ = port.Close()
+			continue
+		}
+		if deviceID, ok := d.cachedDeviceID(); ok {
+			if id, err := dev.GetDeviceIDContext(ctx); err != nil || id != deviceID {
+				_ = port.Close()
+				continue
+			}
+		}
+		return dev, p, nil
+	}
+
+	return nil, PortInfo{}, fmt.Errorf("no matching device found on %d ports", len(ports))
+}
+
+// restoreSettings restores the input mode and the recorded sweep settings after reconnecting. The caller must hold
+// the mutex.
+func (d *Device) restoreSettings(ctx context.Context) {
+	r := d.reconnect
+
+	var cmds []string
+	if mode := d.InputMode(); mode != InputLow {
+		cmds = append(cmds, fmt.Sprintf("mode %s input", mode))
+	}
+	if r.sweepMode != "" {
+		cmds = append(cmds, "sweep "+r.sweepMode)
+	}
+	if r.sweep != nil {
+		cmds = append(cmds, fmt.Sprintf("sweep %d %d %d", r.sweep.Start, r.sweep.Stop, r.sweep.Points))
+	}
+
+	for _, cmd := range cmds {
+		if _, err := sendCommand(ctx, d.logger, d.port, cmd, d.responseTimeout); err != nil {
+			d.logger.Warn("failed to restore setting", "cmd", cmd, "err", err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package tinysa
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+// fakePorts replaces the serial port enumeration and opening with simulators for the duration of the test.
+type fakePorts struct {
+	mu     sync.Mutex
+	ports  []PortInfo
+	sims   map[string]*tinysatest.Simulator
+	silent map[string]bool
+}
+
+func newFakePorts(t *testing.T) *fakePorts {
+	t.Helper()
+
+	f := &fakePorts{sims: make(map[string]*tinysatest.Simulator), silent: make(map[string]bool)}
+	origList, origOpen := listPorts, openPort
+	listPorts = func() ([]PortInfo, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return slices.Clone(f.ports), nil
+	}
+	openPort = func(name string, _ int) (Transport, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.silent[name] {
+			return silentTransport{}, nil
+		}
+		sim, ok := f.sims[name]
+		if !ok {
+			return nil, fmt.Errorf("no such port %s", name)
+		}
+		return sim, nil
+	}
+	t.Cleanup(func() { listPorts, openPort = origList, origOpen })
+
+	return f
+}
+
+// attach adds a simulator with the given device id on a port with the given name and USB serial number.
+func (f *fakePorts) attach(name string, serialNumber string, model tinysatest.Model, deviceID uint) *tinysatest.Simulator {
+	sim := tinysatest.New(model)
+	_, _ = sim.Write([]byte(fmt.Sprintf("deviceid %d\r\n", deviceID)))
+	_, _ = sim.Read(make([]byte, 1024))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ports = append(f.ports, PortInfo{Name: name, IsUSB: true, VID: "0483", PID: "5740", SerialNumber: serialNumber})
+	f.sims[name] = sim
+	return sim
+}
+
+// attachSilent adds a port with tinySA USB ids that never answers, e.g. a device still booting.
+func (f *fakePorts) attachSilent(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ports = append(f.ports, PortInfo{Name: name, IsUSB: true, VID: "0483", PID: "5740"})
+	f.silent[name] = true
+}
+
+// silentTransport accepts all writes and never returns data.
+type silentTransport struct{}
+
+func (silentTransport) Read([]byte) (int, error) {
+	time.Sleep(5 * time.Millisecond)
+	return 0, nil
+}
+func (silentTransport) Write(p []byte) (int, error)        { return len(p), nil }
+func (silentTransport) Close() error                       { return nil }
+func (silentTransport) SetReadTimeout(time.Duration) error { return nil }
+
+// detach closes the simulator on the given port and removes the port.
+func (f *fakePorts) detach(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_ = f.sims[name].Close()
+	delete(f.sims, name)
+	f.ports = slices.DeleteFunc(f.ports, func(p PortInfo) bool { return p.Name == name })
+}
+
+func TestAutoReconnect(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "400", tinysatest.ModelUltra, 7)
+
+	dev, err := NewDevice("/dev/ttyACM0", WithReadTimeout(10*time.Millisecond), WithAutoReconnect(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	if err = dev.SetSweepStartStop(433000000, 435000000); err != nil {
+		t.Fatal(err)
+	}
+	if err = dev.SetSweepMode(SweepModePrecise); err != nil {
+		t.Fatal(err)
+	}
+	if err = dev.SetInputMode(InputHigh); err != nil {
+		t.Fatal(err)
+	}
+
+	// The device reappears on another port, next to another analyzer.
+	ports.detach("/dev/ttyACM0")
+	ports.attach("/dev/ttyACM1", "401", tinysatest.ModelUltra, 8)
+	sim := ports.attach("/dev/ttyACM2", "400", tinysatest.ModelUltra, 7)
+
+	mv, err := dev.GetBatteryVoltage()
+	if err != nil {
+		t.Fatalf("GetBatteryVoltage() after reconnect error = %v", err)
+	}
+	if mv == 0 {
+		t.Error("GetBatteryVoltage() = 0 after reconnect")
+	}
+
+	cmds := sim.Commands()
+	for _, want := range []string{"mode high input", "sweep precise", "sweep 433000000 435000000 450", "vbat"} {
+		if !slices.Contains(cmds, want) {
+			t.Errorf("commands after reconnect %q do not contain %q", cmds, want)
+		}
+	}
+}
+
+func TestAutoReconnectMatchesDeviceID(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "", tinysatest.ModelBasic, 1)
+
+	dev, err := NewDevice("/dev/ttyACM0", WithReadTimeout(10*time.Millisecond), WithAutoReconnect(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	ports.detach("/dev/ttyACM0")
+	other := ports.attach("/dev/ttyACM1", "", tinysatest.ModelBasic, 2)
+	sim := ports.attach("/dev/ttyACM2", "", tinysatest.ModelBasic, 1)
+
+	if _, err = dev.GetBatteryVoltage(); err != nil {
+		t.Fatalf("GetBatteryVoltage() after reconnect error = %v", err)
+	}
+	if slices.Contains(other.Commands(), "vbat") {
+		t.Error("command was sent to the device with another device id")
+	}
+	if !slices.Contains(sim.Commands(), "vbat") {
+		t.Error("command was not sent to the reconnected device")
+	}
+}
+
+func TestAutoReconnectFailed(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "400", tinysatest.ModelBasic, 1)
+
+	dev, err := NewDevice("/dev/ttyACM0", WithReadTimeout(10*time.Millisecond), WithAutoReconnect(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	ports.detach("/dev/ttyACM0")
+
+	if _, err = dev.GetBatteryVoltage(); !errors.Is(err, ErrReconnectFailed) {
+		t.Fatalf("GetBatteryVoltage() error = %v, want %v", err, ErrReconnectFailed)
+	}
+
+	// The device is reconnected by the next command once it is back.
+	ports.attach("/dev/ttyACM0", "400", tinysatest.ModelBasic, 1)
+	if _, err = dev.GetBatteryVoltage(); err != nil {
+		t.Fatalf("GetBatteryVoltage() after device is back error = %v", err)
+	}
+}
+
+func TestAutoReconnectDeadline(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "", tinysatest.ModelBasic, 1)
+
+	dev, err := NewDevice("/dev/ttyACM0", WithReadTimeout(10*time.Millisecond),
+		WithResponseTimeout(200*time.Millisecond), WithAutoReconnect(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	// Only a silent port and the device behind foreign USB ids are left.
+	ports.detach("/dev/ttyACM0")
+	ports.attachSilent("/dev/ttyACM1")
+	foreign := ports.attach("/dev/rfcomm0", "", tinysatest.ModelBasic, 1)
+	ports.ports[len(ports.ports)-1].VID, ports.ports[len(ports.ports)-1].PID = "1d6b", "0002"
+
+	begin := time.Now()
+	if _, err = dev.GetBatteryVoltage(); !errors.Is(err, ErrReconnectFailed) {
+		t.Fatalf("GetBatteryVoltage() error = %v, want %v", err, ErrReconnectFailed)
+	}
+	if elapsed := time.Since(begin); elapsed > 600*time.Millisecond {
+		t.Errorf("reconnect gave up after %s, want shortly after the 100ms timeout", elapsed)
+	}
+	if cmds := foreign.Commands(); len(cmds) > 1 {
+		t.Errorf("port with foreign USB ids was probed: %q", cmds)
+	}
+}
+
+func TestAutoReconnectRecorder(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "400", tinysatest.ModelBasic, 1)
+
+	var session bytes.Buffer
+	dev, err := NewDevice("/dev/ttyACM0", WithReadTimeout(10*time.Millisecond), WithAutoReconnect(time.Second),
+		WithRecorder(&session))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	ports.detach("/dev/ttyACM0")
+	ports.attach("/dev/ttyACM1", "400", tinysatest.ModelBasic, 1)
+	if _, err = dev.GetBatteryVoltage(); err != nil {
+		t.Fatalf("GetBatteryVoltage() after reconnect error = %v", err)
+	}
+
+	out := session.String()
+	if n := strings.Count(out, sessionHeader); n != 1 {
+		t.Errorf("session contains %d headers, want 1", n)
+	}
+	entries, err := readSession(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("failed to read session: %s", err.Error())
+	}
+	if !slices.ContainsFunc(entries, func(e sessionEntry) bool { return e.dir == sessionSent && string(e.data) == "vbat\r\n" }) {
+		t.Error("session does not contain the command sent after reconnecting")
+	}
+}
+
+func TestNoAutoReconnect(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "400", tinysatest.ModelBasic, 1)
+
+	dev, err := NewDevice("/dev/ttyACM0", WithReadTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	ports.detach("/dev/ttyACM0")
+	ports.attach("/dev/ttyACM1", "400", tinysatest.ModelBasic, 1)
+
+	if _, err = dev.GetBatteryVoltage(); err == nil {
+		t.Fatal("GetBatteryVoltage() without auto reconnect succeeded after the port was lost")
+	}
+}
@@ -0,0 +1,933 @@
+package tinysa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+// newTestDevice creates a *Device connected to a simulator of the given model.
+func newTestDevice(t *testing.T, model tinysatest.Model) (*Device, *tinysatest.Simulator) {
+	t.Helper()
+
+	sim := tinysatest.New(model)
+	dev, err := NewDeviceFromTransport(sim,
+		WithReadTimeout(10*time.Millisecond),
+		WithResponseTimeout(500*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create device: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = dev.Close() })
+
+	return dev, sim
+}
+
+// lastCommand returns the last command received by the simulator.
+func lastCommand(sim *tinysatest.Simulator) string {
+	cmds := sim.Commands()
+	if len(cmds) == 0 {
+		return ""
+	}
+	return cmds[len(cmds)-1]
+}
+
+func TestDeviceProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   tinysatest.Model
+		want    Model
+		version string
+		hw      string
+		width   int
+		height  int
+	}{
+		{name: "basic", model: tinysatest.ModelBasic, want: ModelBasic, version: "1.4-175-g1419ca3", hw: "0.3.1", width: 320, height: 280},
+		{name: "ultra", model: tinysatest.ModelUltra, want: ModelUltra, version: "1.4-197-gaa78ccc", hw: "0.4.5.1", width: 480, height: 320},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, _ := newTestDevice(t, tt.model)
+
+			if dev.Model() != tt.want {
+				t.Errorf("Model() = %s, want %s", dev.Model(), tt.want)
+			}
+			if dev.Version() != tt.version {
+				t.Errorf("Version() = %q, want %q", dev.Version(), tt.version)
+			}
+			if dev.HardwareVersion() != tt.hw {
+				t.Errorf("HardwareVersion() = %q, want %q", dev.HardwareVersion(), tt.hw)
+			}
+			width, height := dev.ScreenResolution()
+			if width != tt.width || height != tt.height {
+				t.Errorf("ScreenResolution() = %d, %d, want %d, %d", width, height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestDeviceSweep(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(d *Device) error
+		want Sweep
+	}{
+		{name: "start", set: func(d *Device) error { return d.SetSweepStart(100e6) }, want: Sweep{100e6, 800e6, 450}},
+		{name: "stop", set: func(d *Device) error { return d.SetSweepStop(200e6) }, want: Sweep{0, 200e6, 450}},
+		{name: "center", set: func(d *Device) error { return d.SetSweepCenter(300e6) }, want: Sweep{0, 700e6, 450}},
+		{name: "span", set: func(d *Device) error { return d.SetSweepSpan(100e6) }, want: Sweep{350e6, 450e6, 450}},
+		{name: "cw", set: func(d *Device) error { return d.SetSweepContinuousWave(433e6) }, want: Sweep{433e6, 433e6, 450}},
+		{name: "start stop", set: func(d *Device) error { return d.SetSweepStartStop(100e6, 120e6) }, want: Sweep{100e6, 120e6, 450}},
+		{name: "start stop points", set: func(d *Device) error { return d.SetSweepStartStopWithPoints(100e6, 120e6, 101) }, want: Sweep{100e6, 120e6, 101}},
+		{name: "points", set: func(d *Device) error { return d.SetSweepPoints(51) }, want: Sweep{0, 800e6, 51}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+			if err := tt.set(dev); err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			got, err := dev.GetSweep()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if got != tt.want {
+				t.Errorf("GetSweep() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceSweepStatus(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	if err := dev.PauseSweep(); err != nil {
+		t.Fatal(err)
+	}
+	status, err := dev.GetSweepStatus()
+	if err != nil || status != SweepStatusPaused {
+		t.Errorf("GetSweepStatus() = %s, %v, want %s", status, err, SweepStatusPaused)
+	}
+
+	if err = dev.ResumeSweep(); err != nil {
+		t.Fatal(err)
+	}
+	status, err = dev.GetSweepStatus()
+	if err != nil || status != SweepStatusResumed {
+		t.Errorf("GetSweepStatus() = %s, %v, want %s", status, err, SweepStatusResumed)
+	}
+}
+
+func TestDeviceGetTraceData(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelBasic)
+	sim.SetSignal(100e6, -20)
+
+	if err := dev.SetSweepStartStopWithPoints(50e6, 150e6, 101); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := dev.GetTraceData(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(data) != 101 {
+		t.Fatalf("expected 101 points, got %d", len(data))
+	}
+
+	for i, d := range data {
+		wantFreq := uint64(50e6 + i*1e6)
+		if d.Trace != 1 || d.Point != uint(i) || d.Frequency != wantFreq {
+			t.Errorf("point %d: got %+v, want trace 1, point %d, frequency %d", i, d, i, wantFreq)
+		}
+	}
+	if data[50].Value != -20 {
+		t.Errorf("expected signal of -20 dBm at point 50, got %.2f", data[50].Value)
+	}
+}
+
+func TestDeviceScanRaw(t *testing.T) {
+	for _, model := range []tinysatest.Model{tinysatest.ModelBasic, tinysatest.ModelUltra} {
+		t.Run(string(model), func(t *testing.T) {
+			dev, sim := newTestDevice(t, model)
+			sim.SetSignal(100e6, -20)
+
+			data, err := dev.ScanRaw(50e6, 150e6, 101)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if len(data) != 101 {
+				t.Fatalf("expected 101 points, got %d", len(data))
+			}
+
+			for i, d := range data {
+				wantFreq := uint64(50e6 + i*1e6)
+				if d.Point != uint(i) || d.Frequency != wantFreq {
+					t.Errorf("point %d: got %+v, want point %d, frequency %d", i, d, i, wantFreq)
+				}
+				if d.Value > -20 || d.Value < -105 {
+					t.Errorf("point %d: value %.2f dBm out of expected range", i, d.Value)
+				}
+			}
+			if math.Abs(data[50].Value+20) > 1.0/32 {
+				t.Errorf("expected signal of -20 dBm at point 50, got %.2f", data[50].Value)
+			}
+		})
+	}
+}
+
+func TestDeviceScanRawInvalid(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	if _, err := dev.ScanRaw(50e6, 150e6, 1); err == nil {
+		t.Errorf("expected error for a single point")
+	}
+	if _, err := dev.ScanRaw(150e6, 50e6, 101); err == nil {
+		t.Errorf("expected error for start above stop")
+	}
+}
+
+func TestDeviceScan(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+	sim.SetSignal(100e6, -20)
+
+	sweep := Sweep{Start: 50e6, Stop: 150e6, Points: 101}
+	res, err := dev.Scan(context.Background(), sweep, ScanOutputFrequency|ScanOutputMeasured)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if res.Sweep != sweep || len(res.Points) != 101 {
+		t.Fatalf("unexpected scan result: sweep %+v, %d points", res.Sweep, len(res.Points))
+	}
+	for i, p := range res.Points {
+		wantFreq := uint64(50e6 + i*1e6)
+		if p.Point != uint(i) || p.Frequency != wantFreq || p.Stored != 0 {
+			t.Errorf("point %d: got %+v, want frequency %d", i, p, wantFreq)
+		}
+	}
+	if res.Points[50].Measured != -20 {
+		t.Errorf("expected signal of -20 dBm at point 50, got %.2f", res.Points[50].Measured)
+	}
+	if got := lastCommand(sim); got != "scan 50000000 150000000 101 3" {
+		t.Errorf("sent command %q", got)
+	}
+
+	if _, err = dev.Scan(context.Background(), sweep, 8); err == nil {
+		t.Errorf("expected error for invalid outputs")
+	}
+}
+
+func TestDeviceRBW(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   tinysatest.Model
+		rbw     uint64
+		cmd     string
+		wantErr error
+	}{
+		{name: "basic 3 khz", model: tinysatest.ModelBasic, rbw: 3e3, cmd: "rbw 3"},
+		{name: "basic 600 khz", model: tinysatest.ModelBasic, rbw: 600e3, cmd: "rbw 600"},
+		{name: "basic 200 hz", model: tinysatest.ModelBasic, rbw: 200, wantErr: ErrOptionNotSupportedByModel},
+		{name: "basic 850 khz", model: tinysatest.ModelBasic, rbw: 850e3, wantErr: ErrOptionNotSupportedByModel},
+		{name: "ultra 200 hz", model: tinysatest.ModelUltra, rbw: 200, cmd: "rbw 0.2"},
+		{name: "ultra 850 khz", model: tinysatest.ModelUltra, rbw: 850e3, cmd: "rbw 850"},
+		{name: "ultra 5 khz", model: tinysatest.ModelUltra, rbw: 5e3, wantErr: ErrOptionNotSupportedByModel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, sim := newTestDevice(t, tt.model)
+
+			err := dev.SetRBW(tt.rbw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetRBW(%d) error = %v, want %v", tt.rbw, err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got := lastCommand(sim); got != tt.cmd {
+				t.Errorf("sent command %q, want %q", got, tt.cmd)
+			}
+
+			rbw, err := dev.GetRBW()
+			if err != nil || rbw != (RBW{Frequency: tt.rbw}) {
+				t.Errorf("GetRBW() = %+v, %v, want %d Hz", rbw, err, tt.rbw)
+			}
+		})
+	}
+}
+
+func TestDeviceRBWAuto(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	if err := dev.SetRBW(10e3); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.SetRBWAuto(); err != nil {
+		t.Fatal(err)
+	}
+	rbw, err := dev.GetRBW()
+	if err != nil || !rbw.Auto {
+		t.Errorf("GetRBW() = %+v, %v, want auto", rbw, err)
+	}
+}
+
+func TestDeviceAttenuation(t *testing.T) {
+	for _, model := range []tinysatest.Model{tinysatest.ModelBasic, tinysatest.ModelUltra} {
+		t.Run(string(model), func(t *testing.T) {
+			dev, sim := newTestDevice(t, model)
+
+			atten, err := dev.GetAttenuation()
+			if err != nil || !atten.Auto {
+				t.Errorf("GetAttenuation() = %+v, %v, want auto", atten, err)
+			}
+
+			if err = dev.SetAttenuation(31); err != nil {
+				t.Fatal(err)
+			}
+			if got := lastCommand(sim); got != "attenuate 31" {
+				t.Errorf("sent command %q, want %q", got, "attenuate 31")
+			}
+			atten, err = dev.GetAttenuation()
+			if err != nil || atten != (Attenuation{Level: 31}) {
+				t.Errorf("GetAttenuation() = %+v, %v, want 31 dB", atten, err)
+			}
+
+			if err = dev.SetAttenuation(32); !errors.Is(err, ErrValueOutOfRange) {
+				t.Errorf("SetAttenuation(32) error = %v, want %v", err, ErrValueOutOfRange)
+			}
+
+			if err = dev.SetAttenuationAuto(); err != nil {
+				t.Fatal(err)
+			}
+			if got := lastCommand(sim); got != "attenuate auto" {
+				t.Errorf("sent command %q, want %q", got, "attenuate auto")
+			}
+		})
+	}
+}
+
+func TestDeviceTrigger(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+
+	tests := []struct {
+		name      string
+		set       func() error
+		want      string
+		shouldErr bool
+	}{
+		{name: "mode auto", set: func() error { return dev.SetTriggerMode(TriggerAuto) }, want: "trigger auto"},
+		{name: "mode normal", set: func() error { return dev.SetTriggerMode(TriggerNormal) }, want: "trigger normal"},
+		{name: "invalid mode", set: func() error { return dev.SetTriggerMode("burst") }, shouldErr: true},
+		{name: "level", set: func() error { return dev.SetTriggerLevel(-52.5) }, want: "trigger -52.5"},
+		{name: "edge down", set: func() error { return dev.SetTriggerEdge(TriggerEdgeDown) }, want: "trigger down"},
+		{name: "edge up", set: func() error { return dev.SetTriggerEdge(TriggerEdgeUp) }, want: "trigger up"},
+		{name: "invalid edge", set: func() error { return dev.SetTriggerEdge("both") }, shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(sim.Commands())
+			err := tt.set()
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("error = %v, wantErr = %v", err, tt.shouldErr)
+			}
+			if tt.shouldErr {
+				if len(sim.Commands()) != before {
+					t.Errorf("invalid argument sent command %q", lastCommand(sim))
+				}
+				return
+			}
+			if got := lastCommand(sim); got != tt.want {
+				t.Errorf("sent command %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceWaitForTrigger(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+	sim.SetSignal(400e6, -80)
+
+	if err := dev.SetTriggerLevel(-50); err != nil {
+		t.Fatal(err)
+	}
+
+	// The carrier stays below the trigger level, so the sweep never completes.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := dev.WaitForTrigger(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForTrigger() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		sim.SetSignal(400e6, -30)
+	}()
+
+	data, err := dev.WaitForTrigger(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(data) != 450 {
+		t.Errorf("got %d points, want 450", len(data))
+	}
+
+	status, err := dev.GetSweepStatus()
+	if err != nil || status != SweepStatusPaused {
+		t.Errorf("GetSweepStatus() = %s, %v, want %s", status, err, SweepStatusPaused)
+	}
+}
+
+func TestDeviceWaitForTriggerPausedBefore(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+
+	// The sweep is paused before, and arming the trigger does not resume it on its own.
+	if err := dev.PauseSweep(); err != nil {
+		t.Fatal(err)
+	}
+	sim.Handle("trigger", func([]string) []byte { return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := dev.WaitForTrigger(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForTrigger() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	cmds := sim.Commands()
+	if i := slices.Index(cmds, "resume"); i < 0 || !slices.Contains(cmds[i:], "trigger single") {
+		t.Errorf("sent commands %q, want resume before trigger single", cmds)
+	}
+}
+
+func TestDeviceZeroSpan(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+
+	err := dev.SetZeroSpan(ZeroSpan{Frequency: 433.92e6, RBW: 100e3, SweepTime: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sweep cw 433920000", "rbw 100", "sweeptime 20000u"}
+	if cmds := sim.Commands(); !slices.Equal(cmds[len(cmds)-3:], want) {
+		t.Errorf("sent commands %q, want %q", cmds[len(cmds)-3:], want)
+	}
+
+	sweepTime, err := dev.GetSweepTime()
+	if err != nil || sweepTime != 20*time.Millisecond {
+		t.Errorf("GetSweepTime() = %s, %v, want %s", sweepTime, err, 20*time.Millisecond)
+	}
+
+	samples, err := dev.GetZeroSpanData(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(samples) != 450 {
+		t.Fatalf("got %d samples, want 450", len(samples))
+	}
+	if samples[0].Offset != 0 || samples[449].Offset != 20*time.Millisecond {
+		t.Errorf("sample offsets %s..%s, want 0s..20ms", samples[0].Offset, samples[449].Offset)
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Offset <= samples[i-1].Offset {
+			t.Fatalf("sample offset %d not increasing: %s after %s", i, samples[i].Offset, samples[i-1].Offset)
+		}
+	}
+
+	if err = dev.SetZeroSpan(ZeroSpan{Frequency: 433.92e6}); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"sweep cw 433920000", "rbw auto", "sweeptime 0u"}
+	if cmds := sim.Commands(); !slices.Equal(cmds[len(cmds)-3:], want) {
+		t.Errorf("sent commands %q, want %q", cmds[len(cmds)-3:], want)
+	}
+}
+
+func TestDeviceSDCard(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+
+	// The prompt inside the file content must not end the response early.
+	content := []byte("freq,level\r\nch> 100000000,-90\r\n")
+	sim.AddSDFile("SA_0002.csv", content)
+	sim.AddSDFile("SA_0001.bmp", make([]byte, 1024))
+	sim.AddSDFile("error.bmp", make([]byte, 16))
+
+	files, err := dev.ListSDFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SDFile{{Name: "SA_0001.bmp", Size: 1024}, {Name: "SA_0002.csv", Size: uint64(len(content))}, {Name: "error.bmp", Size: 16}}
+	if !slices.Equal(files, want) {
+		t.Errorf("ListSDFiles() = %+v, want %+v", files, want)
+	}
+
+	got, err := dev.ReadSDFile("SA_0002.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("ReadSDFile() content = %q, want %q", got, content)
+	}
+
+	if _, err = dev.ReadSDFile("missing.csv"); err == nil {
+		t.Error("expected error reading missing file")
+	}
+	if _, err = dev.ReadSDFile("with space.csv"); err == nil {
+		t.Error("expected error for file name with space")
+	}
+
+	if err = dev.DeleteSDFile("SA_0001.bmp"); err != nil {
+		t.Fatal(err)
+	}
+	if err = dev.DeleteSDFile("SA_0001.bmp"); err == nil {
+		t.Error("expected error deleting missing file")
+	}
+	// A file name containing "err" must not be mistaken for a failure.
+	if err = dev.DeleteSDFile("error.bmp"); err != nil {
+		t.Errorf("DeleteSDFile(error.bmp) error = %v", err)
+	}
+	files, err = dev.ListSDFiles()
+	if err != nil || len(files) != 1 {
+		t.Errorf("ListSDFiles() after delete = %+v, %v, want 1 file", files, err)
+	}
+
+	// The device must still be in sync after the binary transfers.
+	if _, err = dev.GetBatteryVoltage(); err != nil {
+		t.Errorf("unexpected error after sd card commands: %s", err.Error())
+	}
+}
+
+func TestDeviceSDCardNotSupported(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelBasic)
+
+	if _, err := dev.ListSDFiles(); !errors.Is(err, ErrOptionNotSupportedByModel) {
+		t.Errorf("ListSDFiles() error = %v, want %v", err, ErrOptionNotSupportedByModel)
+	}
+	if _, err := dev.ReadSDFile("a.csv"); !errors.Is(err, ErrOptionNotSupportedByModel) {
+		t.Errorf("ReadSDFile() error = %v, want %v", err, ErrOptionNotSupportedByModel)
+	}
+	if err := dev.DeleteSDFile("a.csv"); !errors.Is(err, ErrOptionNotSupportedByModel) {
+		t.Errorf("DeleteSDFile() error = %v, want %v", err, ErrOptionNotSupportedByModel)
+	}
+}
+
+func TestDeviceGetTrace(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	if err := dev.EnableTrace(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.SetTraceRefLevel(-20); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.SetTraceScale(5); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.SetTraceUnit(TraceUnitDBmV); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Trace{Trace: 2, Unit: TraceUnitDBmV, RefPos: -20, Scale: 5}
+	got, err := dev.GetTrace(2)
+	if err != nil || got != want {
+		t.Errorf("GetTrace(2) = %+v, %v, want %+v", got, err, want)
+	}
+
+	all, err := dev.GetTraceAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("GetTraceAll() returned %d traces, want 2", len(all))
+	}
+
+	if err = dev.DisableTrace(2); err != nil {
+		t.Fatal(err)
+	}
+	if all, err = dev.GetTraceAll(); err != nil || len(all) != 1 {
+		t.Errorf("GetTraceAll() returned %d traces, %v, want 1", len(all), err)
+	}
+}
+
+func TestDeviceMarker(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+	sim.SetSignal(400e6, -25)
+
+	if err := dev.SetSweepStartStopWithPoints(300e6, 500e6, 201); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.MoveMarkerPeak(1); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Marker{Marker: 1, Index: 100, Frequency: 400e6, Value: -25}
+	got, err := dev.GetMarker(1)
+	if err != nil || got != want {
+		t.Errorf("GetMarker(1) = %+v, %v, want %+v", got, err, want)
+	}
+
+	if err = dev.SetMarkerFreq(2, 350e6); err != nil {
+		t.Fatal(err)
+	}
+	all, err := dev.GetMarkerAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[1].Frequency != 350e6 {
+		t.Errorf("GetMarkerAll() = %+v, want two markers, second at 350 MHz", all)
+	}
+
+	if err = dev.DisableMarker(2); err != nil {
+		t.Fatal(err)
+	}
+	if all, err = dev.GetMarkerAll(); err != nil || len(all) != 1 {
+		t.Errorf("GetMarkerAll() = %+v, %v, want one marker", all, err)
+	}
+}
+
+func TestDeviceCapture(t *testing.T) {
+	for _, model := range []tinysatest.Model{tinysatest.ModelBasic, tinysatest.ModelUltra} {
+		t.Run(string(model), func(t *testing.T) {
+			dev, _ := newTestDevice(t, model)
+
+			img, err := dev.Capture()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			width, height := dev.ScreenResolution()
+			if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+				t.Errorf("expected image size %dx%d, got %v", width, height, img.Bounds())
+			}
+		})
+	}
+}
+
+func TestDeviceGetters(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	vbat, err := dev.GetBatteryVoltage()
+	if err != nil || vbat != 4191 {
+		t.Errorf("GetBatteryVoltage() = %d, %v, want 4191", vbat, err)
+	}
+
+	if err = dev.SetBatteryOffsetVoltage(150); err != nil {
+		t.Fatal(err)
+	}
+	offset, err := dev.GetBatteryOffsetVoltage()
+	if err != nil || offset != 150 {
+		t.Errorf("GetBatteryOffsetVoltage() = %d, %v, want 150", offset, err)
+	}
+
+	if err = dev.SetDeviceID(7); err != nil {
+		t.Fatal(err)
+	}
+	id, err := dev.GetDeviceID()
+	if err != nil || id != 7 {
+		t.Errorf("GetDeviceID() = %d, %v, want 7", id, err)
+	}
+
+	version, err := dev.GetVersion()
+	if err != nil || version != "tinySA4_v1.4-197-gaa78ccc\r\nHW Version:V0.4.5.1" {
+		t.Errorf("GetVersion() = %q, %v", version, err)
+	}
+
+	freqs, err := dev.GetTraceFrequencies()
+	if err != nil || len(freqs) != 450 || freqs[449] != 800e6 {
+		t.Errorf("GetTraceFrequencies() returned %d frequencies, %v", len(freqs), err)
+	}
+}
+
+func TestDeviceCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(d *Device) error
+		want string
+	}{
+		{name: "sweep mode", fn: func(d *Device) error { return d.SetSweepMode(SweepModePrecise) }, want: "sweep precise"},
+		{name: "sweep time", fn: func(d *Device) error { return d.SetSweepTime(50 * time.Millisecond) }, want: "sweeptime 50000u"},
+		{name: "enable marker", fn: func(d *Device) error { return d.EnableMarker(3) }, want: "marker 3 on"},
+		{name: "marker trace", fn: func(d *Device) error { return d.SetMarkerTrace(1, 2) }, want: "marker 1 trace 2"},
+		{name: "marker delta", fn: func(d *Device) error { return d.EnableMarkerDelta(2, 1) }, want: "marker 2 delta 1"},
+		{name: "marker delta off", fn: func(d *Device) error { return d.DisableMarkerDelta(2) }, want: "marker 2 delta off"},
+		{name: "marker tracking", fn: func(d *Device) error { return d.EnableMarkerTracking(1) }, want: "marker 1 tracking on"},
+		{name: "marker tracking off", fn: func(d *Device) error { return d.DisableMarkerTracking(1) }, want: "marker 1 tracking off"},
+		{name: "trace calc", fn: func(d *Device) error { return d.EnableTraceCalc(1, TraceCalcMaxH) }, want: "calc 1 maxh"},
+		{name: "trace calc off", fn: func(d *Device) error { return d.DisableTraceCalc(1) }, want: "calc 1 off"},
+		{name: "ref level auto", fn: func(d *Device) error { return d.SetTraceRefLevelAuto() }, want: "trace reflevel auto"},
+		{name: "spur on", fn: func(d *Device) error { return d.EnableSpurRemoval() }, want: "spur on"},
+		{name: "spur off", fn: func(d *Device) error { return d.DisableSpurRemoval() }, want: "spur off"},
+		{name: "spur auto", fn: func(d *Device) error { return d.EnableAutoSpurRemoval() }, want: "spur auto"},
+		{name: "lna on", fn: func(d *Device) error { return d.EnableLNA() }, want: "lna on"},
+		{name: "lna off", fn: func(d *Device) error { return d.DisableLNA() }, want: "lna off"},
+		{name: "menu", fn: func(d *Device) error { return d.TriggerMenu([]uint{6, 4, 2}) }, want: "menu 6 4 2"},
+		{name: "load preset", fn: func(d *Device) error { return d.LoadPreset(1) }, want: "load 1"},
+		{name: "save preset", fn: func(d *Device) error { return d.SavePreset(2) }, want: "save 2"},
+		{name: "reset", fn: func(d *Device) error { return d.Reset(false) }, want: "reset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+
+			if err := tt.fn(dev); err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if got := lastCommand(sim); got != tt.want {
+				t.Errorf("sent command %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceCommandErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler string
+		reply   string
+		fn      func(d *Device) error
+		want    string
+	}{
+		{
+			name:    "sweep",
+			handler: "sweep",
+			reply:   "usage: sweep {start(Hz)} [stop(Hz)] [points]",
+			fn:      func(d *Device) error { return d.SetSweepStart(100e6) },
+			want:    "sweep start 100000000",
+		},
+		{
+			name:    "sweep time",
+			handler: "sweeptime",
+			reply:   "sweeptime?",
+			fn:      func(d *Device) error { return d.SetSweepTime(50 * time.Millisecond) },
+			want:    "sweeptime 50000u",
+		},
+		{
+			name:    "trace",
+			handler: "trace",
+			reply:   "usage: trace {dBm|dBmV|dBuV|RAW|V|Vpp|W}\r\n\ttrace {scale|reflevel} auto|{value}",
+			fn:      func(d *Device) error { return d.SetTraceRefLevel(-20) },
+			want:    "trace reflevel -20",
+		},
+		{
+			name:    "calc",
+			handler: "calc",
+			reply:   "usage: calc [{trace#}] off|minh|maxh|maxd|aver4|aver16|aver|quasip",
+			fn:      func(d *Device) error { return d.EnableTraceCalc(1, TraceCalcMaxH) },
+			want:    "calc 1 maxh",
+		},
+		{
+			name:    "marker",
+			handler: "marker",
+			reply:   "usage: marker [n] [on|off|peak|{freq}|{index}]",
+			fn:      func(d *Device) error { return d.SetMarkerFreq(1, 433e6) },
+			want:    "marker 1 433000000",
+		},
+		{
+			name:    "rbw",
+			handler: "rbw",
+			reply:   "invalid argument",
+			fn:      func(d *Device) error { return d.SetRBW(3e3) },
+			want:    "rbw 3",
+		},
+		{
+			name:    "attenuation",
+			handler: "attenuate",
+			reply:   "usage: attenuate 0-31|auto",
+			fn:      func(d *Device) error { return d.SetAttenuation(10) },
+			want:    "attenuate 10",
+		},
+		{
+			name:    "trigger",
+			handler: "trigger",
+			reply:   "usage: trigger auto|normal|single|{value}",
+			fn:      func(d *Device) error { return d.SetTriggerMode(TriggerSingle) },
+			want:    "trigger single",
+		},
+		{
+			name:    "device id",
+			handler: "deviceid",
+			reply:   "usage: deviceid [{number}]",
+			fn:      func(d *Device) error { return d.SetDeviceID(7) },
+			want:    "deviceid 7",
+		},
+		{
+			name:    "battery offset",
+			handler: "vbat_offset",
+			reply:   "usage: vbat_offset [{0-4095}]",
+			fn:      func(d *Device) error { return d.SetBatteryOffsetVoltage(100) },
+			want:    "vbat_offset 100",
+		},
+		{
+			name:    "load preset",
+			handler: "load",
+			reply:   "usage: load {id}",
+			fn:      func(d *Device) error { return d.LoadPreset(1) },
+			want:    "load 1",
+		},
+		{
+			name:    "save preset",
+			handler: "save",
+			reply:   "Error: preset out of range",
+			fn:      func(d *Device) error { return d.SavePreset(2) },
+			want:    "save 2",
+		},
+		{
+			name:    "lna",
+			handler: "lna",
+			reply:   "usage: lna on|off",
+			fn:      func(d *Device) error { return d.EnableLNA() },
+			want:    "lna on",
+		},
+		{
+			name:    "spur",
+			handler: "spur",
+			reply:   "usage: spur on|off|auto",
+			fn:      func(d *Device) error { return d.EnableSpurRemoval() },
+			want:    "spur on",
+		},
+		{
+			name:    "generator",
+			handler: "freq",
+			reply:   "usage: freq {frequency(Hz)}",
+			fn: func(d *Device) error {
+				gen, err := d.Generator(GeneratorLow)
+				if err != nil {
+					return err
+				}
+				return gen.SetFrequency(100e6)
+			},
+			want: "freq 100000000",
+		},
+		{
+			name:    "sd card delete",
+			handler: "sd_delete",
+			reply:   "err: no file",
+			fn:      func(d *Device) error { return d.DeleteSDFile("a.bmp") },
+			want:    "sd_delete a.bmp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+			sim.Handle(tt.handler, func([]string) []byte { return []byte(tt.reply + "\r\n") })
+
+			err := tt.fn(dev)
+			var cmdErr *CommandError
+			if !errors.As(err, &cmdErr) {
+				t.Fatalf("error = %v, want *CommandError", err)
+			}
+			if cmdErr.Command != tt.want {
+				t.Errorf("CommandError.Command = %q, want %q", cmdErr.Command, tt.want)
+			}
+			if cmdErr.Message != tt.reply {
+				t.Errorf("CommandError.Message = %q, want %q", cmdErr.Message, tt.reply)
+			}
+		})
+	}
+}
+
+func TestDeviceResetDFU(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+	if err := dev.Reset(true); !errors.Is(err, ErrOptionNotSupportedByModel) {
+		t.Errorf("Reset(true) on ultra model error = %v, want %v", err, ErrOptionNotSupportedByModel)
+	}
+
+	dev, sim := newTestDevice(t, tinysatest.ModelBasic)
+	if err := dev.Reset(true); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if got := lastCommand(sim); got != "reset dfu" {
+		t.Errorf("sent command %q, want %q", got, "reset dfu")
+	}
+}
+
+// slowTransport delays every read of the wrapped transport to simulate a slow serial link.
+type slowTransport struct {
+	Transport
+	delay time.Duration
+}
+
+func (s slowTransport) Read(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	return s.Transport.Read(p)
+}
+
+func TestDeviceContextCancelSDRead(t *testing.T) {
+	sim := tinysatest.New(tinysatest.ModelUltra)
+	dev, err := NewDeviceFromTransport(slowTransport{sim, time.Millisecond},
+		WithReadTimeout(10*time.Millisecond),
+		WithResponseTimeout(2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	// The file content contains the prompt, so draining must count the announced bytes.
+	sim.AddSDFile("prompt.txt", bytes.Repeat([]byte("ch> "), 16*1024))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err = dev.ReadSDFileContext(ctx, "prompt.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	sweep, err := dev.GetSweep()
+	if err != nil {
+		t.Fatalf("unexpected error after cancellation: %s", err.Error())
+	}
+	if want := (Sweep{0, 800e6, 450}); sweep != want {
+		t.Errorf("GetSweep() = %+v, want %+v", sweep, want)
+	}
+}
+
+func TestDeviceContextCancelled(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+	sent := len(sim.Commands())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := dev.GetSweepContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(sim.Commands()) != sent {
+		t.Errorf("expected no command to be sent with a cancelled context")
+	}
+}
+
+func TestDeviceContextCancelResync(t *testing.T) {
+	sim := tinysatest.New(tinysatest.ModelUltra)
+	dev, err := NewDeviceFromTransport(slowTransport{sim, time.Millisecond},
+		WithReadTimeout(10*time.Millisecond),
+		WithResponseTimeout(2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err = dev.CaptureContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// The rest of the capture must have been drained, so the next command is in sync again.
+	sweep, err := dev.GetSweep()
+	if err != nil {
+		t.Fatalf("unexpected error after cancellation: %s", err.Error())
+	}
+	if want := (Sweep{0, 800e6, 450}); sweep != want {
+		t.Errorf("GetSweep() = %+v, want %+v", sweep, want)
+	}
+}
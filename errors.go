@@ -1,6 +1,36 @@
 package tinysa
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 // ErrCommandResponseTimeout is returned when a command does not receive a response within the expected timeframe.
 var ErrCommandResponseTimeout = errors.New("command response timeout")
+
+// ErrOptionNotSupportedByModel is returned when a command option or value is not supported by the device model.
+var ErrOptionNotSupportedByModel = errors.New("option not supported by model")
+
+// ErrNotSupportedByFirmware is returned when a command option is not supported by the firmware version of the device.
+var ErrNotSupportedByFirmware = errors.New("option not supported by firmware")
+
+// ErrValueOutOfRange is returned when a value is outside the range supported by the device model.
+var ErrValueOutOfRange = errors.New("value out of range")
+
+// ErrGeneratorClosed is returned when a Generator is used after it has been closed.
+var ErrGeneratorClosed = errors.New("generator closed")
+
+// ErrReconnectFailed is returned when the connection to the device was lost and auto reconnect did not find it again.
+var ErrReconnectFailed = errors.New("reconnect failed")
+
+// CommandError is returned when the firmware rejects a command, e.g. with a usage message for an invalid argument.
+type CommandError struct {
+	Command string // Command as sent, e.g. `sweep start 100`
+	Message string // Response of the firmware, e.g. `usage: sweep {start(Hz)} [stop(Hz)] [points]`
+}
+
+// Error returns the command and the first line of the firmware message.
+func (e *CommandError) Error() string {
+	msg, _, _ := strings.Cut(e.Message, commandTerminator)
+	return "command `" + e.Command + "` failed: " + msg
+}
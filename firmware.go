@@ -0,0 +1,130 @@
+package tinysa
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// firmwareVersionPattern matches a firmware version, e.g. `1.4-197-gaa78ccc`, `v1.4-197` or `1.4`.
+var firmwareVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:-(\d+))?(?:-g([0-9a-f]+))?`)
+
+// FirmwareVersion is a parsed firmware version, e.g. `1.4-197-gaa78ccc` for version 1.4, build 197 (the number of
+// commits since the release) of the git commit aa78ccc.
+type FirmwareVersion struct {
+	Major int    // Major version
+	Minor int    // Minor version
+	Build int    // Build number, 0 for a release
+	Hash  string // Abbreviated git commit hash without the `g` prefix, empty if unknown
+}
+
+// ParseFirmwareVersion parses a firmware version as returned by Device.Version, e.g. `1.4-197-gaa78ccc`.
+func ParseFirmwareVersion(version string) (FirmwareVersion, error) {
+	m := firmwareVersionPattern.FindStringSubmatch(version)
+	if m == nil {
+		return FirmwareVersion{}, fmt.Errorf("invalid firmware version %q", version)
+	}
+
+	var numbers [3]int
+	for i, str := range m[1:4] {
+		if str == "" {
+			continue
+		}
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			return FirmwareVersion{}, fmt.Errorf("invalid firmware version %q: %s", version, err.Error())
+		}
+		numbers[i] = n
+	}
+
+	return FirmwareVersion{Major: numbers[0], Minor: numbers[1], Build: numbers[2], Hash: m[4]}, nil
+}
+
+// String returns the version in the firmware format, e.g. `1.4-197-gaa78ccc`.
+func (v FirmwareVersion) String() string {
+	s := fmt.Sprintf("%d.%d", v.Major, v.Minor)
+	if v.Build > 0 || v.Hash != "" {
+		s += fmt.Sprintf("-%d", v.Build)
+	}
+	if v.Hash != "" {
+		s += "-g" + v.Hash
+	}
+	return s
+}
+
+// IsZero reports whether the version is unknown, e.g. because the firmware reported a version that cannot be parsed.
+func (v FirmwareVersion) IsZero() bool {
+	return v == FirmwareVersion{}
+}
+
+// Compare returns -1 if v is older than other, 1 if v is newer and 0 if both are equal. Versions are compared by
+// major, minor and build number; the git hash is ignored.
+func (v FirmwareVersion) Compare(other FirmwareVersion) int {
+	return cmp.Or(
+		cmp.Compare(v.Major, other.Major),
+		cmp.Compare(v.Minor, other.Minor),
+		cmp.Compare(v.Build, other.Build),
+	)
+}
+
+// AtLeast reports whether v is equal to or newer than other.
+func (v FirmwareVersion) AtLeast(other FirmwareVersion) bool {
+	return v.Compare(other) >= 0
+}
+
+// Capability is a feature whose availability depends on the model and firmware version of the device.
+type Capability int
+
+const (
+	// CapabilityResetDFU is the `dfu` option of `reset`, entering the bootloader for a firmware update.
+	CapabilityResetDFU Capability = iota
+)
+
+// String returns the name of the capability.
+func (c Capability) String() string {
+	switch c {
+	case CapabilityResetDFU:
+		return "reset dfu"
+	default:
+		return fmt.Sprintf("Capability(%d)", int(c))
+	}
+}
+
+// capabilityTable lists for every capability the models supporting it, with the minimum firmware version required. A
+// zero version is supported by all firmware versions.
+type capabilityTable map[Capability]map[Model]FirmwareVersion
+
+// capabilities is the capability table of new devices. No entry requires a minimum firmware version yet: no firmware
+// release is known to have introduced `reset dfu`, and the `log` and `lin` trace calculations stay unsupported (see
+// traceCalcLog). A version is only added once the release introducing an option is known, until then
+// ErrNotSupportedByFirmware is not returned.
+var capabilities = capabilityTable{
+	CapabilityResetDFU: {
+		ModelBasic: {},
+	},
+}
+
+// FirmwareVersion returns the parsed firmware version, which is zero if the version could not be parsed.
+func (d *Device) FirmwareVersion() FirmwareVersion {
+	return d.firmwareVersion
+}
+
+// Supports reports whether the model and firmware version of the device support the capability.
+func (d *Device) Supports(c Capability) bool {
+	return d.checkCapability(c) == nil
+}
+
+// checkCapability returns an error wrapping ErrOptionNotSupportedByModel or ErrNotSupportedByFirmware if the device
+// does not support the capability. If the firmware version is unknown, only the model is checked.
+func (d *Device) checkCapability(c Capability) error {
+	minVersion, ok := d.capabilities[c][d.model]
+	if !ok {
+		return fmt.Errorf("%s not supported by model %s: %w", c, d.model, ErrOptionNotSupportedByModel)
+	}
+	if !d.firmwareVersion.IsZero() && !d.firmwareVersion.AtLeast(minVersion) {
+		return fmt.Errorf("%s requires firmware %s, device has %s: %w", c, minVersion, d.firmwareVersion,
+			ErrNotSupportedByFirmware)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package tinysa
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+func TestParseFirmwareVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      FirmwareVersion
+		shouldErr bool
+	}{
+		{name: "build with hash", input: "1.4-197-gaa78ccc", want: FirmwareVersion{Major: 1, Minor: 4, Build: 197, Hash: "aa78ccc"}},
+		{name: "build", input: "1.4-150", want: FirmwareVersion{Major: 1, Minor: 4, Build: 150}},
+		{name: "release", input: "1.4", want: FirmwareVersion{Major: 1, Minor: 4}},
+		{name: "prefix", input: "v1.3-12-g0123abc", want: FirmwareVersion{Major: 1, Minor: 3, Build: 12, Hash: "0123abc"}},
+		{name: "invalid", input: "gaa78ccc", shouldErr: true},
+		{name: "empty", input: "", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFirmwareVersion(tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("ParseFirmwareVersion(%q) error = %v, shouldErr %v", tt.input, err, tt.shouldErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFirmwareVersion(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if !tt.shouldErr && got.String() != tt.input && "v"+got.String() != tt.input {
+				t.Errorf("String() = %q, want %q", got.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestFirmwareVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.4-197-gaa78ccc", b: "1.4-197-g0000000", want: 0},
+		{a: "1.4-175", b: "1.4-197", want: -1},
+		{a: "1.5", b: "1.4-197", want: 1},
+		{a: "1.4", b: "1.4-1", want: -1},
+		{a: "2.0", b: "1.9-500", want: 1},
+	}
+
+	for _, tt := range tests {
+		a, _ := ParseFirmwareVersion(tt.a)
+		b, _ := ParseFirmwareVersion(tt.b)
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := a.AtLeast(b); got != (tt.want >= 0) {
+			t.Errorf("%s.AtLeast(%s) = %v, want %v", tt.a, tt.b, got, tt.want >= 0)
+		}
+	}
+}
+
+func TestDeviceFirmwareVersion(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	want := FirmwareVersion{Major: 1, Minor: 4, Build: 197, Hash: "aa78ccc"}
+	if got := dev.FirmwareVersion(); got != want {
+		t.Errorf("FirmwareVersion() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDeviceCapabilities(t *testing.T) {
+	basic, _ := newTestDevice(t, tinysatest.ModelBasic)
+	ultra, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	if !basic.Supports(CapabilityResetDFU) || ultra.Supports(CapabilityResetDFU) {
+		t.Error("Supports(CapabilityResetDFU) should only be true for the basic model")
+	}
+}
+
+func TestDeviceCapabilityFirmware(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelBasic)
+	dev.capabilities = capabilityTable{
+		CapabilityResetDFU: {ModelBasic: {Major: 1, Minor: 5}},
+	}
+
+	if dev.Supports(CapabilityResetDFU) {
+		t.Error("Supports(CapabilityResetDFU) = true, want false for older firmware")
+	}
+	if err := dev.Reset(true); !errors.Is(err, ErrNotSupportedByFirmware) {
+		t.Errorf("Reset(true) error = %v, want %v", err, ErrNotSupportedByFirmware)
+	}
+	if got := lastCommand(sim); got == "reset dfu" {
+		t.Error("command was sent despite unsupported firmware")
+	}
+
+	// Devices with an unknown firmware version are only checked by model.
+	dev.firmwareVersion = FirmwareVersion{}
+	if !dev.Supports(CapabilityResetDFU) {
+		t.Error("Supports(CapabilityResetDFU) with unknown firmware = false, want true")
+	}
+}
@@ -0,0 +1,262 @@
+package tinysa
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// GeneratorMode selects the output path of the signal generator.
+type GeneratorMode int
+
+const (
+	// GeneratorLow uses the low output, a clean sine wave with a fine adjustable level.
+	GeneratorLow GeneratorMode = iota
+
+	// GeneratorHigh uses the high output, a stronger but less clean signal at higher frequencies.
+	GeneratorHigh
+)
+
+// String returns the firmware name of the mode.
+func (m GeneratorMode) String() string {
+	switch m {
+	case GeneratorLow:
+		return "low"
+	case GeneratorHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("GeneratorMode(%d)", int(m))
+	}
+}
+
+// Modulation selects the modulation of the generator output.
+type Modulation int
+
+const (
+	// ModulationOff outputs an unmodulated carrier.
+	ModulationOff Modulation = iota
+
+	// ModulationAM enables amplitude modulation.
+	ModulationAM
+
+	// ModulationNFM enables narrow frequency modulation.
+	ModulationNFM
+
+	// ModulationWFM enables wide frequency modulation.
+	ModulationWFM
+)
+
+// String returns the firmware name of the modulation.
+func (m Modulation) String() string {
+	switch m {
+	case ModulationOff:
+		return "off"
+	case ModulationAM:
+		return "AM"
+	case ModulationNFM:
+		return "NFM"
+	case ModulationWFM:
+		return "WFM"
+	default:
+		return fmt.Sprintf("Modulation(%d)", int(m))
+	}
+}
+
+// GeneratorLimits holds the frequency and output level range of a generator mode.
+type GeneratorLimits struct {
+	MinFrequency uint64  // Minimum frequency in Hz
+	MaxFrequency uint64  // Maximum frequency in Hz
+	MinLevel     float64 // Minimum output level in dBm
+	MaxLevel     float64 // Maximum output level in dBm
+}
+
+// Generator controls the device in signal generator (output) mode. It is obtained with Device.Generator and must be
+// closed to restore the analyzer input mode the device was in. A Generator is safe for concurrent use, but analyzer methods should
+// not be used while it is open.
+type Generator struct {
+	dev    *Device
+	mode   GeneratorMode
+	limits GeneratorLimits
+	input  InputMode  // Input mode restored on close
+	mutex  sync.Mutex // Guards closed and serializes the generator commands
+	closed bool
+}
+
+// Generator switches the device to signal generator mode using the given output and returns a Generator to control
+// it. The output stays disabled until EnableOutput is called, and closing the Generator restores the current input
+// mode, see Device.InputMode. Modes without limits for the device model return an
+// error wrapping ErrOptionNotSupportedByModel.
+func (d *Device) Generator(mode GeneratorMode) (*Generator, error) {
+	return d.GeneratorContext(context.Background(), mode)
+}
+
+// GeneratorContext is like Generator but uses ctx to cancel the command.
+func (d *Device) GeneratorContext(ctx context.Context, mode GeneratorMode) (*Generator, error) {
+	d.logger.Info("switching to generator mode", "mode", mode)
+
+	limits, ok := d.generatorLimits[mode]
+	if !ok {
+		return nil, fmt.Errorf("generator mode %s: %w", mode, ErrOptionNotSupportedByModel)
+	}
+
+	if err := d.sendSetCommand(ctx, fmt.Sprintf("mode %s output", mode)); err != nil {
+		return nil, err
+	}
+
+	return &Generator{dev: d, mode: mode, limits: limits, input: d.InputMode()}, nil
+}
+
+// Mode returns the output mode of the generator.
+func (g *Generator) Mode() GeneratorMode {
+	return g.mode
+}
+
+// Limits returns the frequency and level range of the generator mode for the device model.
+func (g *Generator) Limits() GeneratorLimits {
+	return g.limits
+}
+
+// SetFrequency sets the continuous wave frequency in Hz.
+func (g *Generator) SetFrequency(freqHz uint64) error {
+	return g.SetFrequencyContext(context.Background(), freqHz)
+}
+
+// SetFrequencyContext is like SetFrequency but uses ctx to cancel the command.
+func (g *Generator) SetFrequencyContext(ctx context.Context, freqHz uint64) error {
+	g.dev.logger.Info("setting generator frequency", "frequency", freqHz)
+	if err := g.checkFrequency(freqHz); err != nil {
+		return err
+	}
+	return g.send(ctx, fmt.Sprintf("freq %d", freqHz))
+}
+
+// SetLevel sets the output level in dBm.
+func (g *Generator) SetLevel(levelDbm float64) error {
+	return g.SetLevelContext(context.Background(), levelDbm)
+}
+
+// SetLevelContext is like SetLevel but uses ctx to cancel the command.
+func (g *Generator) SetLevelContext(ctx context.Context, levelDbm float64) error {
+	g.dev.logger.Info("setting generator level", "level", levelDbm)
+	if levelDbm < g.limits.MinLevel || levelDbm > g.limits.MaxLevel {
+		return fmt.Errorf("level %g dBm (supported: %g..%g): %w",
+			levelDbm, g.limits.MinLevel, g.limits.MaxLevel, ErrValueOutOfRange)
+	}
+	return g.send(ctx, fmt.Sprintf("level %s", strconv.FormatFloat(levelDbm, 'f', -1, 64)))
+}
+
+// EnableOutput enables the generator output.
+func (g *Generator) EnableOutput() error {
+	return g.EnableOutputContext(context.Background())
+}
+
+// EnableOutputContext is like EnableOutput but uses ctx to cancel the command.
+func (g *Generator) EnableOutputContext(ctx context.Context) error {
+	g.dev.logger.Info("enabling generator output")
+	return g.send(ctx, "output on")
+}
+
+// DisableOutput disables the generator output.
+func (g *Generator) DisableOutput() error {
+	return g.DisableOutputContext(context.Background())
+}
+
+// DisableOutputContext is like DisableOutput but uses ctx to cancel the command.
+func (g *Generator) DisableOutputContext(ctx context.Context) error {
+	g.dev.logger.Info("disabling generator output")
+	return g.send(ctx, "output off")
+}
+
+// SetModulation sets the modulation of the output signal.
+func (g *Generator) SetModulation(modulation Modulation) error {
+	return g.SetModulationContext(context.Background(), modulation)
+}
+
+// SetModulationContext is like SetModulation but uses ctx to cancel the command.
+func (g *Generator) SetModulationContext(ctx context.Context, modulation Modulation) error {
+	g.dev.logger.Info("setting generator modulation", "modulation", modulation)
+	if modulation < ModulationOff || modulation > ModulationWFM {
+		return fmt.Errorf("invalid modulation %d", modulation)
+	}
+	return g.send(ctx, fmt.Sprintf("modulation %s", modulation))
+}
+
+// SetModulationFrequency sets the frequency of the modulating tone in Hz.
+func (g *Generator) SetModulationFrequency(freqHz uint64) error {
+	return g.SetModulationFrequencyContext(context.Background(), freqHz)
+}
+
+// SetModulationFrequencyContext is like SetModulationFrequency but uses ctx to cancel the command.
+func (g *Generator) SetModulationFrequencyContext(ctx context.Context, freqHz uint64) error {
+	g.dev.logger.Info("setting generator modulation frequency", "frequency", freqHz)
+	return g.send(ctx, fmt.Sprintf("modulation freq %d", freqHz))
+}
+
+// SetSweep sweeps the output frequency from start to stop frequency in Hz. Use SetFrequency to return to a
+// continuous wave.
+func (g *Generator) SetSweep(start uint64, stop uint64) error {
+	return g.SetSweepContext(context.Background(), start, stop)
+}
+
+// SetSweepContext is like SetSweep but uses ctx to cancel the command.
+func (g *Generator) SetSweepContext(ctx context.Context, start uint64, stop uint64) error {
+	g.dev.logger.Info("setting generator sweep", "start", start, "stop", stop)
+	if start > stop {
+		return fmt.Errorf("start frequency %d is above stop frequency %d", start, stop)
+	}
+	if err := g.checkFrequency(start); err != nil {
+		return err
+	}
+	if err := g.checkFrequency(stop); err != nil {
+		return err
+	}
+	return g.send(ctx, fmt.Sprintf("sweep %d %d", start, stop))
+}
+
+// Close disables the output and switches the device back to the input mode it was in when the Generator was created.
+// Closing an already closed Generator is a no-op.
+func (g *Generator) Close() error {
+	return g.CloseContext(context.Background())
+}
+
+// CloseContext is like Close but uses ctx to cancel the command.
+func (g *Generator) CloseContext(ctx context.Context) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.closed {
+		return nil
+	}
+	g.dev.logger.Info("switching back to input mode", "mode", g.input)
+
+	if err := g.dev.sendSetCommand(ctx, "output off"); err != nil {
+		return err
+	}
+	if err := g.dev.sendSetCommand(ctx, fmt.Sprintf("mode %s input", g.input)); err != nil {
+		return err
+	}
+
+	g.closed = true
+	return nil
+}
+
+// send sends a command to the device, unless the generator has been closed.
+func (g *Generator) send(ctx context.Context, cmd string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.closed {
+		return ErrGeneratorClosed
+	}
+	return g.dev.sendSetCommand(ctx, cmd)
+}
+
+// checkFrequency returns an error if freqHz is outside the frequency range of the generator mode.
+func (g *Generator) checkFrequency(freqHz uint64) error {
+	if freqHz < g.limits.MinFrequency || freqHz > g.limits.MaxFrequency {
+		return fmt.Errorf("frequency %d Hz (supported: %d..%d): %w",
+			freqHz, g.limits.MinFrequency, g.limits.MaxFrequency, ErrValueOutOfRange)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package tinysa
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+func TestGenerator(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+
+	gen, err := dev.Generator(GeneratorLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lastCommand(sim); got != "mode low output" {
+		t.Errorf("sent command %q, want %q", got, "mode low output")
+	}
+
+	tests := []struct {
+		name string
+		set  func() error
+		want string
+	}{
+		{name: "frequency", set: func() error { return gen.SetFrequency(433.92e6) }, want: "freq 433920000"},
+		{name: "level", set: func() error { return gen.SetLevel(-30.5) }, want: "level -30.5"},
+		{name: "output on", set: gen.EnableOutput, want: "output on"},
+		{name: "output off", set: gen.DisableOutput, want: "output off"},
+		{name: "modulation am", set: func() error { return gen.SetModulation(ModulationAM) }, want: "modulation AM"},
+		{name: "modulation wfm", set: func() error { return gen.SetModulation(ModulationWFM) }, want: "modulation WFM"},
+		{name: "modulation off", set: func() error { return gen.SetModulation(ModulationOff) }, want: "modulation off"},
+		{name: "modulation frequency", set: func() error { return gen.SetModulationFrequency(1000) }, want: "modulation freq 1000"},
+		{name: "sweep", set: func() error { return gen.SetSweep(100e6, 200e6) }, want: "sweep 100000000 200000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.set(); err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if got := lastCommand(sim); got != tt.want {
+				t.Errorf("sent command %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	before := len(sim.Commands())
+	if err = gen.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sim.Commands()[before:], []string{"output off", "mode low input"}; !slices.Equal(got, want) {
+		t.Errorf("close sent commands %q, want %q", got, want)
+	}
+	if err = gen.Close(); err != nil {
+		t.Errorf("second Close() error = %v, want nil", err)
+	}
+	if err = gen.EnableOutput(); !errors.Is(err, ErrGeneratorClosed) {
+		t.Errorf("EnableOutput() after close error = %v, want %v", err, ErrGeneratorClosed)
+	}
+}
+
+func TestGeneratorRestoresInputMode(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelBasic)
+
+	if err := dev.SetInputMode(InputHigh); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastCommand(sim); got != "mode high input" {
+		t.Errorf("sent command %q, want %q", got, "mode high input")
+	}
+	if got := dev.InputMode(); got != InputHigh {
+		t.Errorf("InputMode() = %v, want %v", got, InputHigh)
+	}
+
+	gen, err := dev.Generator(GeneratorLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = gen.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastCommand(sim); got != "mode high input" {
+		t.Errorf("close sent command %q, want %q", got, "mode high input")
+	}
+}
+
+func TestGeneratorConcurrentClose(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+
+	gen, err := dev.Generator(GeneratorLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := len(sim.Commands())
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := gen.EnableOutput(); err != nil && !errors.Is(err, ErrGeneratorClosed) {
+				t.Errorf("EnableOutput() error = %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := gen.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	closes := 0
+	for _, cmd := range sim.Commands()[before:] {
+		if cmd == "mode low input" {
+			closes++
+		}
+	}
+	if closes != 1 {
+		t.Errorf("sent %d close commands, want 1", closes)
+	}
+}
+
+func TestGeneratorLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		model tinysatest.Model
+		mode  GeneratorMode
+		freq  uint64
+		level float64
+		valid bool
+	}{
+		{name: "basic low", model: tinysatest.ModelBasic, mode: GeneratorLow, freq: 100e6, level: -20, valid: true},
+		{name: "basic low frequency too high", model: tinysatest.ModelBasic, mode: GeneratorLow, freq: 400e6, level: -20},
+		{name: "basic low level too high", model: tinysatest.ModelBasic, mode: GeneratorLow, freq: 100e6, level: 0},
+		{name: "basic high", model: tinysatest.ModelBasic, mode: GeneratorHigh, freq: 900e6, level: 10, valid: true},
+		{name: "basic high frequency too high", model: tinysatest.ModelBasic, mode: GeneratorHigh, freq: 2e9, level: 10},
+		{name: "ultra low", model: tinysatest.ModelUltra, mode: GeneratorLow, freq: 700e6, level: -100, valid: true},
+		{name: "ultra low level too low", model: tinysatest.ModelUltra, mode: GeneratorLow, freq: 700e6, level: -120},
+		{name: "ultra high", model: tinysatest.ModelUltra, mode: GeneratorHigh, freq: 2e9, level: 13, valid: true},
+		{name: "ultra high frequency too low", model: tinysatest.ModelUltra, mode: GeneratorHigh, freq: 100e6, level: 13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, _ := newTestDevice(t, tt.model)
+
+			gen, err := dev.Generator(tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = gen.Close() }()
+
+			err = errors.Join(gen.SetFrequency(tt.freq), gen.SetLevel(tt.level))
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error: %s", err.Error())
+			}
+			if !tt.valid && !errors.Is(err, ErrValueOutOfRange) {
+				t.Errorf("error = %v, want %v", err, ErrValueOutOfRange)
+			}
+		})
+	}
+}
+
+func TestGeneratorInvalidMode(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelBasic)
+
+	if _, err := dev.Generator(GeneratorMode(42)); !errors.Is(err, ErrOptionNotSupportedByModel) {
+		t.Errorf("Generator() error = %v, want %v", err, ErrOptionNotSupportedByModel)
+	}
+}
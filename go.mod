@@ -3,11 +3,13 @@ module github.com/kkettinger/go-tinysa
 go 1.24.1
 
 require (
+	github.com/gorilla/websocket v1.5.3
 	go.bug.st/serial v1.6.4
 	golang.org/x/image v0.26.0
+	golang.org/x/term v0.31.0
 )
 
 require (
 	github.com/creack/goselect v0.1.2 // indirect
-	golang.org/x/sys v0.19.0 // indirect
+	golang.org/x/sys v0.32.0 // indirect
 )
@@ -0,0 +1,30 @@
+// Package freq parses frequencies given on the command line.
+package freq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a frequency in Hz, with an optional k, M or G suffix and optional `Hz` unit.
+func Parse(s string) (uint64, error) {
+	str := strings.TrimSuffix(strings.TrimSpace(s), "Hz")
+	multiplier := 1.0
+	if n := len(str); n > 0 {
+		switch str[n-1] {
+		case 'k', 'K':
+			multiplier, str = 1e3, str[:n-1]
+		case 'M':
+			multiplier, str = 1e6, str[:n-1]
+		case 'G':
+			multiplier, str = 1e9, str[:n-1]
+		}
+	}
+
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid frequency %q", s)
+	}
+	return uint64(f*multiplier + 0.5), nil
+}
@@ -0,0 +1,34 @@
+package freq
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      uint64
+		shouldErr bool
+	}{
+		{input: "100000000", want: 100e6},
+		{input: "1e8", want: 100e6},
+		{input: "433.92M", want: 433920000},
+		{input: "433.92MHz", want: 433920000},
+		{input: "2.4G", want: 2400e6},
+		{input: "10k", want: 10e3},
+		{input: "500Hz", want: 500},
+		{input: "-1M", shouldErr: true},
+		{input: "M", shouldErr: true},
+		{input: "", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Parse(%q) error = %v, wantErr = %v", tt.input, err, tt.shouldErr)
+			}
+			if !tt.shouldErr && got != tt.want {
+				t.Errorf("Parse(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package tinysa
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Manager holds several devices and looks them up by device id, USB serial number, USB vendor and product id or port
+// name, so a device can be addressed regardless of the port the operating system assigned to it.
+type Manager struct {
+	mutex   sync.Mutex
+	devices []*Device
+}
+
+// NewManager creates a Manager with all devices found by FindDevices.
+func NewManager(opts ...DeviceOption) (*Manager, error) {
+	devices, err := FindDevices(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{}
+	for _, dev := range devices {
+		m.Add(dev)
+	}
+	return m, nil
+}
+
+// Add adds a device to the manager. Its device id is read once and kept up to date by SetDeviceID; a device whose id
+// cannot be read is only found by its port.
+func (m *Manager) Add(dev *Device) {
+	if _, err := dev.GetDeviceID(); err != nil {
+		dev.logger.Warn("failed to read device id, device is not addressable by id", "err", err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.devices = append(m.devices, dev)
+}
+
+// Remove removes a device from the manager without closing it. It reports whether the device was found.
+func (m *Manager) Remove(dev *Device) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i, d := range m.devices {
+		if d == dev {
+			m.devices = append(m.devices[:i], m.devices[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Devices returns all devices, in the order they were added.
+func (m *Manager) Devices() []*Device {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return slices.Clone(m.devices)
+}
+
+// ByDeviceID returns the device with the given device id, as set by SetDeviceID.
+func (m *Manager) ByDeviceID(id uint) (*Device, bool) {
+	return m.find(func(dev *Device) bool {
+		deviceID, ok := dev.cachedDeviceID()
+		return ok && deviceID == id
+	})
+}
+
+// BySerialNumber returns the device with the given USB serial number.
+func (m *Manager) BySerialNumber(serialNumber string) (*Device, bool) {
+	return m.find(func(dev *Device) bool {
+		return serialNumber != "" && dev.PortInfo().SerialNumber == serialNumber
+	})
+}
+
+// ByPort returns the device connected on the given port name, e.g. /dev/ttyACM0.
+func (m *Manager) ByPort(name string) (*Device, bool) {
+	return m.find(func(dev *Device) bool { return dev.PortInfo().Name == name })
+}
+
+// ByUSBID returns all devices with the given USB vendor and product id, compared case-insensitively.
+func (m *Manager) ByUSBID(vid string, pid string) []*Device {
+	var devices []*Device
+	for _, dev := range m.Devices() {
+		info := dev.PortInfo()
+		if strings.EqualFold(info.VID, vid) && strings.EqualFold(info.PID, pid) {
+			devices = append(devices, dev)
+		}
+	}
+	return devices
+}
+
+// Close closes all devices and removes them from the manager.
+func (m *Manager) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var errs []error
+	for _, dev := range m.devices {
+		errs = append(errs, dev.Close())
+	}
+	m.devices = nil
+	return errors.Join(errs...)
+}
+
+// find returns the first device matching the given function. The manager is not locked while matching, and the
+// device identity is read without waiting for running commands.
+func (m *Manager) find(match func(dev *Device) bool) (*Device, bool) {
+	for _, dev := range m.Devices() {
+		if match(dev) {
+			return dev, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,225 @@
+package tinysa
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+func TestFindDevices(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "400", tinysatest.ModelBasic, 1)
+	ports.attach("/dev/ttyACM1", "401", tinysatest.ModelUltra, 2)
+	ports.ports = append(ports.ports, PortInfo{Name: "/dev/ttyS0"})
+
+	devices, err := FindDevices(WithReadTimeout(10 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("FindDevices() found %d devices, want 2", len(devices))
+	}
+	if devices[1].Model() != ModelUltra {
+		t.Errorf("second device model = %s, want %s", devices[1].Model(), ModelUltra)
+	}
+	want := PortInfo{Name: "/dev/ttyACM1", IsUSB: true, VID: "0483", PID: "5740", SerialNumber: "401"}
+	if got := devices[1].PortInfo(); got != want {
+		t.Errorf("PortInfo() = %+v, want %+v", got, want)
+	}
+}
+
+func TestManager(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "400", tinysatest.ModelBasic, 3)
+	ports.attach("/dev/ttyACM1", "401", tinysatest.ModelUltra, 7)
+	ports.attach("/dev/ttyACM2", "402", tinysatest.ModelUltra, 9)
+
+	m, err := NewManager(WithReadTimeout(10 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if n := len(m.Devices()); n != 3 {
+		t.Fatalf("Devices() = %d devices, want 3", n)
+	}
+
+	dev, ok := m.ByDeviceID(7)
+	if !ok || dev.PortInfo().Name != "/dev/ttyACM1" {
+		t.Errorf("ByDeviceID(7) = %v, %v, want device on /dev/ttyACM1", dev, ok)
+	}
+	if _, ok = m.ByDeviceID(8); ok {
+		t.Error("ByDeviceID(8) found a device")
+	}
+
+	dev, ok = m.BySerialNumber("402")
+	if !ok || dev.PortInfo().Name != "/dev/ttyACM2" {
+		t.Errorf("BySerialNumber(402) = %v, %v, want device on /dev/ttyACM2", dev, ok)
+	}
+	if _, ok = m.BySerialNumber(""); ok {
+		t.Error("BySerialNumber(\"\") found a device")
+	}
+
+	dev, ok = m.ByPort("/dev/ttyACM0")
+	if !ok || dev.Model() != ModelBasic {
+		t.Errorf("ByPort(/dev/ttyACM0) = %v, %v, want basic device", dev, ok)
+	}
+
+	if n := len(m.ByUSBID("0483", "5740")); n != 3 {
+		t.Errorf("ByUSBID(0483, 5740) = %d devices, want 3", n)
+	}
+
+	if !m.Remove(dev) || len(m.Devices()) != 2 {
+		t.Errorf("Remove() did not remove the device")
+	}
+	_ = dev.Close()
+	if err = m.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if n := len(m.Devices()); n != 0 {
+		t.Errorf("Devices() after Close() = %d devices, want 0", n)
+	}
+}
+
+func TestManagerDeviceIDChange(t *testing.T) {
+	ports := newFakePorts(t)
+	sim := ports.attach("/dev/ttyACM0", "400", tinysatest.ModelUltra, 7)
+
+	m, err := NewManager(WithReadTimeout(10 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	dev, ok := m.ByDeviceID(7)
+	if !ok {
+		t.Fatal("ByDeviceID(7) found no device")
+	}
+	if err = dev.SetDeviceID(9); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok = m.ByDeviceID(7); ok {
+		t.Error("ByDeviceID(7) found a device after SetDeviceID(9)")
+	}
+	if _, ok = m.ByDeviceID(9); !ok {
+		t.Error("ByDeviceID(9) found no device after SetDeviceID(9)")
+	}
+
+	// Lookups do not wait for a running command.
+	release := make(chan struct{})
+	sim.Handle("vbat", func([]string) []byte {
+		<-release
+		return []byte("4000 mV\r\n")
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = dev.GetBatteryVoltage()
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	found := make(chan bool)
+	go func() {
+		_, ok := m.ByPort("/dev/ttyACM0")
+		found <- ok
+	}()
+	select {
+	case ok = <-found:
+		if !ok {
+			t.Error("ByPort(/dev/ttyACM0) found no device during a command")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Error("ByPort() blocked by a running command")
+	}
+	close(release)
+	<-done
+}
+
+func TestFindDevicesFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []DeviceOption
+		want      []string
+		shouldErr bool
+	}{
+		{name: "all", want: []string{"/dev/ttyACM0", "/dev/ttyACM1"}},
+		{name: "model", opts: []DeviceOption{WithModel(ModelUltra)}, want: []string{"/dev/ttyACM1"}},
+		{name: "min version", opts: []DeviceOption{WithMinFirmwareVersion("1.4-180")}, want: []string{"/dev/ttyACM1"}},
+		{name: "min version release", opts: []DeviceOption{WithMinFirmwareVersion("1.4")}, want: []string{"/dev/ttyACM0", "/dev/ttyACM1"}},
+		{name: "min version too new", opts: []DeviceOption{WithMinFirmwareVersion("1.5")}},
+		{name: "invalid min version", opts: []DeviceOption{WithMinFirmwareVersion("latest")}, shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ports := newFakePorts(t)
+			ports.attach("/dev/ttyACM0", "400", tinysatest.ModelBasic, 1)
+			ports.attach("/dev/ttyACM1", "401", tinysatest.ModelUltra, 2)
+			other := ports.attach("/dev/rfcomm0", "", tinysatest.ModelUltra, 3)
+			ports.ports[2].VID, ports.ports[2].PID = "1d6b", "0002"
+
+			devices, err := FindDevices(append(tt.opts, WithReadTimeout(10*time.Millisecond))...)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("FindDevices() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+
+			var got []string
+			for _, dev := range devices {
+				got = append(got, dev.PortInfo().Name)
+				_ = dev.Close()
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FindDevices() ports = %q, want %q", got, tt.want)
+			}
+			// The only command is the device id set by attach.
+			if cmds := other.Commands(); len(cmds) > 1 {
+				t.Errorf("port with foreign USB ids was probed: %q", cmds)
+			}
+		})
+	}
+}
+
+func TestFindDevicesContext(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attachSilent("/dev/ttyACM0")
+
+	// Without cancelling, the silent port is probed for several response timeouts.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	_, err := FindDevicesContext(ctx, WithReadTimeout(10*time.Millisecond), WithResponseTimeout(200*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FindDevicesContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Errorf("FindDevicesContext() returned after %s, want shortly after cancel", elapsed)
+	}
+}
+
+func TestFindDevice(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "400", tinysatest.ModelBasic, 1)
+	second := ports.attach("/dev/ttyACM1", "401", tinysatest.ModelUltra, 2)
+
+	dev, err := FindDevice(WithReadTimeout(10 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	if dev.PortInfo().Name != "/dev/ttyACM0" {
+		t.Errorf("FindDevice() port = %s, want first port /dev/ttyACM0", dev.PortInfo().Name)
+	}
+	if _, err = second.Write([]byte("vbat\r\n")); !errors.Is(err, tinysatest.ErrClosed) {
+		t.Errorf("second device was not closed, write error = %v", err)
+	}
+
+	if _, err = FindDevice(WithReadTimeout(10*time.Millisecond), WithModel("tinySA5")); err == nil {
+		t.Error("FindDevice() with unknown model succeeded")
+	}
+}
@@ -2,10 +2,34 @@ package tinysa
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// parseAttenuationResponse parses the response of the attenuate command without arguments into an Attenuation struct.
+// The firmware prints the usage first, followed by the current setting in dB or `auto`.
+//
+// Example response: `usage: attenuate 0..31|auto\r\n10dB`
+func parseAttenuationResponse(response string) (Attenuation, error) {
+	value, err := parseSettingValue(response)
+	if err != nil {
+		return Attenuation{}, err
+	}
+
+	if strings.EqualFold(value, "auto") {
+		return Attenuation{Auto: true}, nil
+	}
+
+	level, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(value, "dB")), 10, 0)
+	if err != nil {
+		return Attenuation{}, fmt.Errorf("invalid attenuation %q: %s", value, err.Error())
+	}
+
+	return Attenuation{Level: uint(level)}, nil
+}
+
 // parseBatteryResponse parses a battery response into a uint voltage (mV).
 //
 // Example response: `4191 mV`
@@ -27,6 +51,54 @@ func parseBatteryResponse(response string) (uint, error) {
 	return uint(vbat), nil
 }
 
+// parseCommandErrorResponse returns a *CommandError if the response to a command without output reports an error:
+// a usage message, the `<command>?` reply to unknown commands, or an error or invalid argument message. Other
+// responses are no error.
+//
+// Example response: `usage: sweep {start(Hz)} [stop(Hz)] [points]\r\n\tsweep {start|stop|center|span|cw} {freq(Hz)}`
+func parseCommandErrorResponse(cmd string, response string) error {
+	msg := strings.TrimSpace(response)
+	if msg == "" {
+		return nil
+	}
+
+	first, _, _ := strings.Cut(msg, commandTerminator)
+	first = strings.ToLower(strings.TrimSpace(first))
+
+	name := cmd
+	if fields := strings.Fields(cmd); len(fields) > 0 {
+		name = fields[0]
+	}
+
+	if strings.HasPrefix(first, "usage:") ||
+		first == strings.ToLower(name)+"?" ||
+		strings.HasPrefix(first, "err") ||
+		strings.Contains(first, "error") ||
+		strings.Contains(first, "invalid") {
+		return &CommandError{Command: cmd, Message: msg}
+	}
+	return nil
+}
+
+// parseFrequenciesResponse parses a frequencies response into a uint64 slice of frequencies in Hz.
+//
+// Example response: `100000000\r\n100200000\r\n100400000`
+func parseFrequenciesResponse(response string) ([]uint64, error) {
+	freqList := strings.Split(response, commandTerminator)
+
+	result := make([]uint64, len(freqList))
+	for i, freq := range freqList {
+		freqInt, err := strconv.ParseUint(freq, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse frequency %q as int: %s", freq, err.Error())
+		}
+
+		result[i] = freqInt
+	}
+
+	return result, nil
+}
+
 // parseMarkerResponseLine parses a single line of a marker response into a Marker struct.
 //
 // Example response: `1 216 522167037 -9.08e+01`
@@ -64,6 +136,136 @@ func parseMarkerResponseLine(line string) (Marker, error) {
 	}, nil
 }
 
+// parseRBWResponse parses the response of the rbw command without arguments into an RBW struct. The firmware prints
+// the usage first, followed by the current setting in kHz or `auto`.
+//
+// Example response: `usage: rbw 0.2..850|auto\r\n3kHz`
+func parseRBWResponse(response string) (RBW, error) {
+	value, err := parseSettingValue(response)
+	if err != nil {
+		return RBW{}, err
+	}
+
+	if strings.EqualFold(value, "auto") {
+		return RBW{Auto: true}, nil
+	}
+
+	kHz, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "kHz")), 64)
+	if err != nil {
+		return RBW{}, fmt.Errorf("invalid rbw %q: %s", value, err.Error())
+	}
+	if kHz <= 0 {
+		return RBW{}, fmt.Errorf("invalid rbw %q", value)
+	}
+
+	return RBW{Frequency: uint64(math.Round(kHz * 1e3))}, nil
+}
+
+// parseScanResponseLine parses a single line of a scan response into a ScanPoint, with the columns selected by outputs.
+//
+// Example response (all outputs): `433920000 -2.95e+01 -1.00e+02 `
+func parseScanResponseLine(line string, outputs ScanOutputs) (ScanPoint, error) {
+	fields := strings.Fields(line)
+
+	expected := 0
+	for _, o := range []ScanOutputs{ScanOutputFrequency, ScanOutputMeasured, ScanOutputStored} {
+		if outputs.Has(o) {
+			expected++
+		}
+	}
+	if len(fields) != expected {
+		return ScanPoint{}, fmt.Errorf("expected %d fields, got %d", expected, len(fields))
+	}
+
+	var p ScanPoint
+	var err error
+	if outputs.Has(ScanOutputFrequency) {
+		if p.Frequency, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+			return ScanPoint{}, fmt.Errorf("invalid frequency %q: %s", fields[0], err.Error())
+		}
+		fields = fields[1:]
+	}
+	if outputs.Has(ScanOutputMeasured) {
+		if p.Measured, err = strconv.ParseFloat(fields[0], 64); err != nil {
+			return ScanPoint{}, fmt.Errorf("invalid measured value %q: %s", fields[0], err.Error())
+		}
+		fields = fields[1:]
+	}
+	if outputs.Has(ScanOutputStored) {
+		if p.Stored, err = strconv.ParseFloat(fields[0], 64); err != nil {
+			return ScanPoint{}, fmt.Errorf("invalid stored value %q: %s", fields[0], err.Error())
+		}
+	}
+
+	return p, nil
+}
+
+// parseSDListResponse parses a sd_list response into a slice of SDFile.
+//
+// Example response: `SA_0001.bmp 307322\r\nSA_0002.csv 9540`
+func parseSDListResponse(response string) ([]SDFile, error) {
+	var files []SDFile
+	for _, line := range strings.Split(response, commandTerminator) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "err:") {
+			return nil, fmt.Errorf("sd card error: %s", line)
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("expected 2 fields, got %d", len(fields))
+		}
+
+		size, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file size %q: %s", fields[1], err.Error())
+		}
+
+		files = append(files, SDFile{Name: fields[0], Size: size})
+	}
+
+	return files, nil
+}
+
+// parseSDDeleteResponse returns a *CommandError if the sd_delete response does not report success. The status is the
+// trailing token of the `delete:` line, the file name in between may contain anything, e.g. "err".
+//
+// Example response: `delete: SA_0001.bmp OK`
+func parseSDDeleteResponse(cmd string, response string) error {
+	msg := strings.TrimSpace(response)
+	if strings.HasPrefix(msg, "delete:") {
+		fields := strings.Fields(msg)
+		if fields[len(fields)-1] == "OK" {
+			return nil
+		}
+		return &CommandError{Command: cmd, Message: msg}
+	}
+	if err := parseCommandErrorResponse(cmd, response); err != nil {
+		return err
+	}
+	return &CommandError{Command: cmd, Message: msg}
+}
+
+// parseSettingValue returns the single value line of a setting command called without arguments. Such commands print
+// their usage first, followed by the current value.
+//
+// Example response: `usage: rbw 0.2..850|auto\r\n3kHz`
+func parseSettingValue(response string) (string, error) {
+	var values []string
+	for _, line := range strings.Split(response, commandTerminator) {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "usage:") {
+			values = append(values, line)
+		}
+	}
+	if len(values) != 1 {
+		return "", fmt.Errorf("expected 1 value line, got %d", len(values))
+	}
+	return values[0], nil
+}
+
 // parseSweepResponse parses a sweep response into a Sweep struct.
 //
 // Example response: `450000000 600000000 450`
@@ -95,6 +297,27 @@ func parseSweepResponse(response string) (Sweep, error) {
 	}, nil
 }
 
+// parseSweepTimeResponse parses the response of the sweeptime command without arguments into a duration. The
+// firmware prints the usage first, followed by the current sweep time in seconds.
+//
+// Example response: `usage: sweeptime 0.003..60\r\n0.05s`
+func parseSweepTimeResponse(response string) (time.Duration, error) {
+	value, err := parseSettingValue(response)
+	if err != nil {
+		return 0, err
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "s")), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sweep time %q: %s", value, err.Error())
+	}
+	if seconds < 0 {
+		return 0, fmt.Errorf("invalid sweep time %q", value)
+	}
+
+	return time.Duration(math.Round(seconds * float64(time.Second))), nil
+}
+
 // parseTraceValueResponseLine parses a single line of a trace value response into a TraceValue struct.
 //
 // Example response: `trace 1 value 442 -108.88`
@@ -126,6 +349,24 @@ func parseTraceValueResponseLine(line string) (TraceValue, error) {
 	}, nil
 }
 
+// parseTraceValuesResponse parses a multi-line trace value response into a TraceValue slice.
+//
+// Example response: `trace 1 value 0 -108.88\r\ntrace 1 value 1 -107.50`
+func parseTraceValuesResponse(response string) ([]TraceValue, error) {
+	lines := strings.Split(response, commandTerminator)
+
+	data := make([]TraceValue, len(lines))
+	for i, line := range lines {
+		v, err := parseTraceValueResponseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trace result: %s", err.Error())
+		}
+		data[i] = v
+	}
+
+	return data, nil
+}
+
 // parseTraceResponseLine parses a single line of a trace response into a Trace struct.
 //
 // Example response: `1: dBm -30.000000000 10.000000000`
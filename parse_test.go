@@ -1,9 +1,44 @@
 package tinysa
 
 import (
+	"errors"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
+func TestParseAttenuationResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      Attenuation
+		shouldErr bool
+	}{
+		{name: "level", input: "usage: attenuate 0..31|auto\r\n10dB", want: Attenuation{Level: 10}},
+		{name: "zero level", input: "usage: attenuate 0..31|auto\r\n0dB", want: Attenuation{Level: 0}},
+		{name: "with space", input: "usage: attenuate 0..31|auto\r\n31 dB", want: Attenuation{Level: 31}},
+		{name: "auto", input: "usage: attenuate 0..31|auto\r\nauto", want: Attenuation{Auto: true}},
+		{name: "without usage", input: "5dB", want: Attenuation{Level: 5}},
+		{name: "usage only", input: "usage: attenuate 0..31|auto", shouldErr: true},
+		{name: "negative level", input: "-5dB", shouldErr: true},
+		{name: "non-integer level", input: "high", shouldErr: true},
+		{name: "empty input", input: "", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAttenuationResponse(tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("parseAttenuationResponse(%q) error = %v, wantErr = %v", tt.input, err, tt.shouldErr)
+			}
+			if !tt.shouldErr && got != tt.want {
+				t.Errorf("parseAttenuationResponse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseBatteryVoltageLine(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -32,6 +67,75 @@ func TestParseBatteryVoltageLine(t *testing.T) {
 	}
 }
 
+func TestParseCommandErrorResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		cmd       string
+		input     string
+		shouldErr bool
+	}{
+		{name: "empty", cmd: "sweep start 100", input: ""},
+		{name: "whitespace", cmd: "sweep start 100", input: "\r\n"},
+		{name: "usage", cmd: "sweep start x", input: "usage: sweep {start(Hz)} [stop(Hz)] [points]", shouldErr: true},
+		{
+			name:      "multi-line usage",
+			cmd:       "trace 9 view on",
+			input:     "usage: trace {dBm|dBmV|dBuV|RAW|V|Vpp|W}\r\n\ttrace {scale|reflevel} auto|{value}",
+			shouldErr: true,
+		},
+		{name: "unknown command", cmd: "sweeptime 1u", input: "sweeptime?", shouldErr: true},
+		{name: "invalid argument", cmd: "rbw 7", input: "invalid argument", shouldErr: true},
+		{name: "error", cmd: "save 9", input: "Error: preset out of range", shouldErr: true},
+		{name: "err prefix", cmd: "sd_delete a", input: "err: no file", shouldErr: true},
+		{name: "informational output", cmd: "deviceid 7", input: "deviceid 7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseCommandErrorResponse(tt.cmd, tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("parseCommandErrorResponse(%q, %q) error = %v, wantErr = %v", tt.cmd, tt.input, err, tt.shouldErr)
+			}
+			if !tt.shouldErr {
+				return
+			}
+
+			var cmdErr *CommandError
+			if !errors.As(err, &cmdErr) {
+				t.Fatalf("parseCommandErrorResponse(%q, %q) error = %T, want *CommandError", tt.cmd, tt.input, err)
+			}
+			if cmdErr.Command != tt.cmd || cmdErr.Message != strings.TrimSpace(tt.input) {
+				t.Errorf("CommandError = %+v, want command %q and message %q", cmdErr, tt.cmd, tt.input)
+			}
+		})
+	}
+}
+
+func TestParseSDDeleteResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		shouldErr bool
+	}{
+		{name: "ok", input: "delete: SA_0001.bmp OK"},
+		{name: "ok with err in name", input: "delete: error.bmp OK"},
+		{name: "ok with err in name suffix", input: "delete: ferret.csv OK"},
+		{name: "missing file", input: "delete: SA_0001.bmp err", shouldErr: true},
+		{name: "missing file with err in name", input: "delete: error.bmp err", shouldErr: true},
+		{name: "usage", input: "usage: sd_delete {filename}", shouldErr: true},
+		{name: "empty", input: "", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseSDDeleteResponse("sd_delete x", tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("parseSDDeleteResponse(%q) error = %v, wantErr = %v", tt.input, err, tt.shouldErr)
+			}
+		})
+	}
+}
+
 func TestParseMarkerResultLine(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -100,6 +204,144 @@ func TestParseMarkerResultLine(t *testing.T) {
 	}
 }
 
+func TestParseRBWResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      RBW
+		shouldErr bool
+	}{
+		{name: "integer khz", input: "usage: rbw 0.2..850|auto\r\n3kHz", want: RBW{Frequency: 3000}},
+		{name: "fractional khz", input: "usage: rbw 0.2..850|auto\r\n0.2kHz", want: RBW{Frequency: 200}},
+		{name: "with space", input: "usage: rbw 3..600|auto\r\n300 kHz", want: RBW{Frequency: 300000}},
+		{name: "auto", input: "usage: rbw 0.2..850|auto\r\nauto", want: RBW{Auto: true}},
+		{name: "without usage", input: "850kHz", want: RBW{Frequency: 850000}},
+		{name: "usage only", input: "usage: rbw 0.2..850|auto", shouldErr: true},
+		{name: "invalid value", input: "usage: rbw 0.2..850|auto\r\nwide", shouldErr: true},
+		{name: "zero value", input: "0kHz", shouldErr: true},
+		{name: "empty input", input: "", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRBWResponse(tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("parseRBWResponse(%q) error = %v, wantErr = %v", tt.input, err, tt.shouldErr)
+			}
+			if !tt.shouldErr && got != tt.want {
+				t.Errorf("parseRBWResponse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScanResponseLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		outputs   ScanOutputs
+		want      ScanPoint
+		shouldErr bool
+	}{
+		{
+			name:    "all outputs",
+			input:   "433920000 -2.95e+01 -1.00e+02 ",
+			outputs: ScanOutputFrequency | ScanOutputMeasured | ScanOutputStored,
+			want:    ScanPoint{Frequency: 433920000, Measured: -29.5, Stored: -100},
+		},
+		{
+			name:    "frequency only",
+			input:   "433920000 ",
+			outputs: ScanOutputFrequency,
+			want:    ScanPoint{Frequency: 433920000},
+		},
+		{
+			name:    "measured and stored",
+			input:   "-2.95e+01 -1.00e+02",
+			outputs: ScanOutputMeasured | ScanOutputStored,
+			want:    ScanPoint{Measured: -29.5, Stored: -100},
+		},
+		{
+			name:      "too few fields",
+			input:     "433920000",
+			outputs:   ScanOutputFrequency | ScanOutputMeasured,
+			shouldErr: true,
+		},
+		{
+			name:      "too many fields",
+			input:     "433920000 -2.95e+01 -1.00e+02",
+			outputs:   ScanOutputFrequency | ScanOutputMeasured,
+			shouldErr: true,
+		},
+		{
+			name:      "non-integer frequency",
+			input:     "freq -2.95e+01",
+			outputs:   ScanOutputFrequency | ScanOutputMeasured,
+			shouldErr: true,
+		},
+		{
+			name:      "non-float measured",
+			input:     "433920000 level",
+			outputs:   ScanOutputFrequency | ScanOutputMeasured,
+			shouldErr: true,
+		},
+		{
+			name:      "non-float stored",
+			input:     "stored",
+			outputs:   ScanOutputStored,
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseScanResponseLine(tt.input, tt.outputs)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("parseScanResponseLine(%q) error = %v, wantErr = %v", tt.input, err, tt.shouldErr)
+			}
+			if !tt.shouldErr && got != tt.want {
+				t.Errorf("parseScanResponseLine(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSDListResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      []SDFile
+		shouldErr bool
+	}{
+		{
+			name:  "valid input",
+			input: "SA_0001.bmp 307322\r\nSA_0002.csv 9540",
+			want:  []SDFile{{Name: "SA_0001.bmp", Size: 307322}, {Name: "SA_0002.csv", Size: 9540}},
+		},
+		{
+			name:  "file name starting with err",
+			input: "error.bmp 16",
+			want:  []SDFile{{Name: "error.bmp", Size: 16}},
+		},
+		{name: "empty card", input: "", want: nil},
+		{name: "card error", input: "err: no card", shouldErr: true},
+		{name: "missing size", input: "SA_0001.bmp", shouldErr: true},
+		{name: "non-integer size", input: "SA_0001.bmp big", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSDListResponse(tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("parseSDListResponse(%q) error = %v, wantErr = %v", tt.input, err, tt.shouldErr)
+			}
+			if !tt.shouldErr && !slices.Equal(got, tt.want) {
+				t.Errorf("parseSDListResponse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseSweepResponse(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -172,6 +414,37 @@ func TestParseSweepResponse(t *testing.T) {
 	}
 }
 
+func TestParseSweepTimeResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      time.Duration
+		shouldErr bool
+	}{
+		{name: "seconds", input: "usage: sweeptime 0.003..60\r\n1.5s", want: 1500 * time.Millisecond},
+		{name: "milliseconds", input: "usage: sweeptime 0.003..60\r\n0.05s", want: 50 * time.Millisecond},
+		{name: "microseconds", input: "usage: sweeptime 0.003..60\r\n0.003125s", want: 3125 * time.Microsecond},
+		{name: "zero", input: "usage: sweeptime 0.003..60\r\n0s", want: 0},
+		{name: "without unit", input: "2", want: 2 * time.Second},
+		{name: "usage only", input: "usage: sweeptime 0.003..60", shouldErr: true},
+		{name: "negative", input: "-1s", shouldErr: true},
+		{name: "non-numeric", input: "fast", shouldErr: true},
+		{name: "empty input", input: "", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSweepTimeResponse(tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("parseSweepTimeResponse(%q) error = %v, wantErr = %v", tt.input, err, tt.shouldErr)
+			}
+			if !tt.shouldErr && got != tt.want {
+				t.Errorf("parseSweepTimeResponse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseTraceValue(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,66 @@
+package tinysa
+
+import (
+	"log/slog"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+// PortInfo describes the serial port of a device and, if available, its USB details.
+type PortInfo struct {
+	Name         string // Port name, e.g. /dev/ttyACM0 or COM3
+	IsUSB        bool   // Port is a USB device
+	VID          string // USB vendor id as hex string, e.g. 0483
+	PID          string // USB product id as hex string, e.g. 5740
+	SerialNumber string // USB serial number
+}
+
+// listPorts returns all serial ports. USB details are read via the enumerator; if the enumerator is not available on
+// the platform, the plain port names are returned. It is a variable so tests can replace it.
+var listPorts = func() ([]PortInfo, error) {
+	details, err := enumerator.GetDetailedPortsList()
+	if err == nil {
+		ports := make([]PortInfo, 0, len(details))
+		for _, d := range details {
+			ports = append(ports, PortInfo{
+				Name:         d.Name,
+				IsUSB:        d.IsUSB,
+				VID:          d.VID,
+				PID:          d.PID,
+				SerialNumber: d.SerialNumber,
+			})
+		}
+		return ports, nil
+	}
+
+	names, err := serial.GetPortsList()
+	if err != nil {
+		return nil, err
+	}
+	ports := make([]PortInfo, 0, len(names))
+	for _, name := range names {
+		ports = append(ports, PortInfo{Name: name})
+	}
+	return ports, nil
+}
+
+// openPort opens the serial port with the given name and baud rate. It is a variable so tests can replace it.
+var openPort = func(name string, baudRate int) (Transport, error) {
+	return serial.Open(name, &serial.Mode{BaudRate: baudRate})
+}
+
+// lookupPort returns the details of the port with the given name, or only the name if the port is not listed.
+func lookupPort(logger *slog.Logger, name string) PortInfo {
+	ports, err := listPorts()
+	if err != nil {
+		logger.Debug("failed to list serial ports", "err", err)
+		return PortInfo{Name: name}
+	}
+	for _, p := range ports {
+		if p.Name == name {
+			return p
+		}
+	}
+	return PortInfo{Name: name}
+}
@@ -2,13 +2,14 @@ package tinysa
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"strings"
 	"time"
-
-	"go.bug.st/serial"
 )
 
 const (
@@ -20,11 +21,37 @@ const (
 
 	// responseTimeoutTries is the maximum number of retries after a response timeout.
 	responseTimeoutTries = 3
+
+	// scanRawCommand is the command that returns sweep values in the binary `{`...`}` framed format.
+	scanRawCommand = "scanraw"
+
+	// scanRawRecordMarker precedes every 16-bit value of a scanraw response.
+	scanRawRecordMarker = 'x'
+
+	// sdReadCommand is the command that returns a file from the SD card, prefixed by its 32-bit little-endian size.
+	sdReadCommand = "sd_read"
+
+	// sdReadSizeLen is the length of the size prefix of a sd_read response.
+	sdReadSizeLen = 4
 )
 
+// transportError is returned when reading from or writing to the transport failed, e.g. because the port is gone.
+type transportError struct {
+	op  string
+	err error
+}
+
+func (e *transportError) Error() string {
+	return e.op + ": " + e.err.Error()
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
 // sendCommand wraps sendCommandBinary, converting its []byte response to a string.
-func sendCommand(logger *slog.Logger, port serial.Port, cmd string, responseTimeout time.Duration) (string, error) {
-	response, err := sendCommandBinary(logger, port, cmd, responseTimeout)
+func sendCommand(ctx context.Context, logger *slog.Logger, port Transport, cmd string, responseTimeout time.Duration) (string, error) {
+	response, err := sendCommandBinary(ctx, logger, port, cmd, responseTimeout)
 	if err != nil {
 		return "", err
 	}
@@ -32,7 +59,9 @@ func sendCommand(logger *slog.Logger, port serial.Port, cmd string, responseTime
 }
 
 // sendCommandBinary sends a command to the tinySA and handles both string and binary responses.
-func sendCommandBinary(logger *slog.Logger, port serial.Port, cmd string, responseTimeout time.Duration) ([]byte, error) {
+// If ctx is cancelled while waiting for the response, the port is drained up to the next response prompt before
+// returning the context error, so the following command starts in sync.
+func sendCommandBinary(ctx context.Context, logger *slog.Logger, port Transport, cmd string, responseTimeout time.Duration) ([]byte, error) {
 	fullCmd := cmd + commandTerminator
 
 	logger.Debug("sending command", "cmd", cmd)
@@ -42,9 +71,9 @@ func sendCommandBinary(logger *slog.Logger, port serial.Port, cmd string, respon
 	var response bytes.Buffer
 	tries := 0
 	for {
-		r, err := sendCommandAndRead(logger, port, fullCmd, responseTimeout)
+		r, err := sendCommandAndRead(ctx, logger, port, fullCmd, responseTimeout)
 		if err != nil {
-			if errors.Is(err, ErrCommandResponseTimeout) && tries < responseTimeoutTries {
+			if errors.Is(err, ErrCommandResponseTimeout) && tries < responseTimeoutTries && ctx.Err() == nil {
 				logger.Warn("response timeout detected, re-trying", "tries", tries)
 				tries++
 				continue
@@ -82,6 +111,16 @@ func handleResponse(logger *slog.Logger, fullCmd string, response []byte) ([]byt
 		return nil, fmt.Errorf("response is too short, missing response prompt")
 	}
 
+	// The scanraw response is framed binary data, which never ends with a line terminator.
+	if isCommand(fullCmd, scanRawCommand) {
+		return decodeScanRawResponse(logger, response[:len(response)-len(responsePrompt)])
+	}
+
+	// The sd_read response is length-prefixed binary data, unless the firmware reports an error as text.
+	if isCommand(fullCmd, sdReadCommand) {
+		return decodeSDReadResponse(logger, response[:len(response)-len(responsePrompt)])
+	}
+
 	// If the response is just the response prompt, it was command without any response, and we are finished here.
 	if bytes.Equal(response, []byte(responsePrompt)) {
 		logger.Debug("only response prompt found, no additional response")
@@ -102,23 +141,117 @@ func handleResponse(logger *slog.Logger, fullCmd string, response []byte) ([]byt
 	return response, nil
 }
 
+// isCommand reports whether the full command line invokes the given command.
+func isCommand(fullCmd string, command string) bool {
+	return fullCmd == command+commandTerminator || strings.HasPrefix(fullCmd, command+" ")
+}
+
+// decodeScanRawResponse validates the framing of a scanraw response and returns the 16-bit little-endian values
+// without frame and record markers, i.e. two bytes per point.
+//
+// Example response: `{x\x20\x09x\x1f\x09}` (two points)
+func decodeScanRawResponse(logger *slog.Logger, response []byte) ([]byte, error) {
+	if len(response) < 2 || response[0] != '{' || response[len(response)-1] != '}' {
+		logger.Error("scanraw response is not framed by curly braces", "len", len(response))
+		return nil, fmt.Errorf("scanraw response is not framed by curly braces")
+	}
+
+	records := response[1 : len(response)-1]
+	if len(records)%3 != 0 {
+		logger.Error("scanraw response has incomplete record", "len", len(records))
+		return nil, fmt.Errorf("scanraw response has incomplete record, %d bytes", len(records))
+	}
+
+	values := make([]byte, 0, len(records)/3*2)
+	for i := 0; i < len(records); i += 3 {
+		if records[i] != scanRawRecordMarker {
+			logger.Error("scanraw record marker missing", "offset", i+1)
+			return nil, fmt.Errorf("scanraw record marker missing at offset %d", i+1)
+		}
+		values = append(values, records[i+1], records[i+2])
+	}
+
+	logger.Debug("decoded scanraw response", "points", len(values)/2)
+	return values, nil
+}
+
+// decodeSDReadResponse validates the size prefix of a sd_read response and returns the file content. Text responses
+// (e.g. `err: no file`) are returned as error.
+//
+// Example response: `\x05\x00\x00\x00hello` (five byte file)
+func decodeSDReadResponse(logger *slog.Logger, response []byte) ([]byte, error) {
+	size, ok := sdReadSize(response)
+	if !ok {
+		msg := strings.TrimSpace(string(response))
+		logger.Error("sd_read failed", "response", msg)
+		return nil, fmt.Errorf("sd_read failed: %s", msg)
+	}
+
+	data := response[sdReadSizeLen:]
+	if uint64(len(data)) != size {
+		logger.Error("unexpected sd_read length", "expected", size, "got", len(data))
+		return nil, fmt.Errorf("expected %d sd_read bytes, got %d", size, len(data))
+	}
+
+	logger.Debug("decoded sd_read response", "len", len(data))
+	return data, nil
+}
+
+// sdReadSize returns the file size of a sd_read response without echo. It returns false if the size prefix has not
+// been received yet, or if the response is a text message instead.
+func sdReadSize(response []byte) (uint64, bool) {
+	if len(response) < sdReadSizeLen {
+		return 0, false
+	}
+	for _, prefix := range []string{"err", "usage:"} {
+		if bytes.HasPrefix(response, []byte(prefix)) {
+			return 0, false
+		}
+	}
+	return uint64(binary.LittleEndian.Uint32(response)), true
+}
+
+// responseComplete reports whether the response to fullCmd has been received completely. Length-prefixed responses
+// are complete once all announced bytes and the prompt arrived, since their data may contain the prompt itself.
+func responseComplete(fullCmd string, response []byte) bool {
+	if isCommand(fullCmd, sdReadCommand) && len(response) > len(fullCmd) {
+		if size, ok := sdReadSize(response[len(fullCmd):]); ok {
+			return uint64(len(response)) >= uint64(len(fullCmd)+sdReadSizeLen+len(responsePrompt))+size &&
+				bytes.HasSuffix(response, []byte(responsePrompt))
+		}
+	}
+	return bytes.HasSuffix(response, []byte(responsePrompt))
+}
+
 // sendCommandAndRead sends a request over the serial port and reads the response.
-func sendCommandAndRead(logger *slog.Logger, port serial.Port, fullCmd string, responseTimeout time.Duration) (bytes.Buffer, error) {
+func sendCommandAndRead(ctx context.Context, logger *slog.Logger, port Transport, fullCmd string, responseTimeout time.Duration) (bytes.Buffer, error) {
+	if err := ctx.Err(); err != nil {
+		logger.Warn("context done before sending command", "cmd", fullCmd, "err", err)
+		return bytes.Buffer{}, err
+	}
+
 	logger.Debug("sending full command", "cmd", fullCmd)
 	if _, err := port.Write([]byte(fullCmd)); err != nil {
 		logger.Error("failed to write command", "cmd", fullCmd, "err", err)
-		return bytes.Buffer{}, fmt.Errorf("cmd write failed: %s", err.Error())
+		return bytes.Buffer{}, &transportError{op: "cmd write failed", err: err}
 	}
 
 	buffer := make([]byte, 512)
 	var response bytes.Buffer
 
-	timeout := time.Now().Add(responseTimeout)
+	readCtx, cancel := context.WithTimeout(ctx, responseTimeout)
+	defer cancel()
 
 	logger.Debug("waiting for response")
 	for {
-		if time.Now().After(timeout) {
-			logger.Error("timeout occurred while reading response", "timeout", timeout.String())
+		if readCtx.Err() != nil {
+			// The caller cancelled, so the device is still sending; drain it to stay in sync.
+			if err := ctx.Err(); err != nil {
+				logger.Warn("command cancelled while reading response, resynchronising", "err", err)
+				drainResponse(logger, port, fullCmd, response.Bytes(), responseTimeout)
+				return bytes.Buffer{}, err
+			}
+			logger.Error("timeout occurred while reading response", "timeout", responseTimeout.String())
 			return bytes.Buffer{}, ErrCommandResponseTimeout
 		}
 
@@ -129,14 +262,14 @@ func sendCommandAndRead(logger *slog.Logger, port serial.Port, fullCmd string, r
 				break
 			}
 			logger.Error("failed to read response", "err", err)
-			return bytes.Buffer{}, fmt.Errorf("failed to read response: %s", err.Error())
+			return bytes.Buffer{}, &transportError{op: "failed to read response", err: err}
 		}
 
 		response.Write(buffer[:n])
 		logger.Debug("read bytes", "n", n, "len", response.Len())
 
-		// Check if we received the response prompt.
-		if bytes.HasSuffix(response.Bytes(), []byte(responsePrompt)) {
+		// Check if we received the complete response, up to the response prompt.
+		if responseComplete(fullCmd, response.Bytes()) {
 			logger.Debug("response prompt detected, reading complete", "buffer", string(buffer[:n]))
 			break
 		}
@@ -146,3 +279,38 @@ func sendCommandAndRead(logger *slog.Logger, port serial.Port, fullCmd string, r
 
 	return response, nil
 }
+
+// drainResponse discards incoming bytes until the response to fullCmd is complete (see responseComplete), or no data
+// arrived for idleTimeout. The already received part of the response is passed as received.
+func drainResponse(logger *slog.Logger, port Transport, fullCmd string, received []byte, idleTimeout time.Duration) {
+	buffer := make([]byte, 512)
+	response := bytes.Clone(received)
+	deadline := time.Now().Add(idleTimeout)
+
+	// Length-prefixed responses are kept to count the announced bytes, since their data may contain the prompt.
+	counted := isCommand(fullCmd, sdReadCommand)
+
+	for !responseComplete(fullCmd, response) {
+		if time.Now().After(deadline) {
+			logger.Warn("no response prompt received while draining, port may be out of sync")
+			return
+		}
+
+		n, err := port.Read(buffer)
+		if err != nil {
+			logger.Warn("failed to read while draining", "err", err)
+			return
+		}
+		if n > 0 {
+			deadline = time.Now().Add(idleTimeout)
+		}
+
+		// Otherwise only the last bytes are needed to detect the prompt.
+		response = append(response, buffer[:n]...)
+		if !counted && len(response) > len(responsePrompt) {
+			response = response[len(response)-len(responsePrompt):]
+		}
+	}
+
+	logger.Debug("drained response up to prompt")
+}
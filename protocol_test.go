@@ -33,6 +33,54 @@ func TestHandleResponse(t *testing.T) {
 			response: []byte("sweep start 120000000\r\nch> "),
 			want:     []byte(""),
 		},
+		{
+			name:     "scanraw response",
+			fullCmd:  "scanraw 0 100 2\r\n",
+			response: []byte("scanraw 0 100 2\r\n{x\x20\x09x\x0d\x0a}ch> "),
+			want:     []byte("\x20\x09\x0d\x0a"),
+		},
+		{
+			name:      "scanraw response without frame",
+			fullCmd:   "scanraw 0 100 2\r\n",
+			response:  []byte("scanraw 0 100 2\r\nx\x20\x09x\x0d\x0ach> "),
+			expectErr: true,
+		},
+		{
+			name:      "scanraw response with incomplete record",
+			fullCmd:   "scanraw 0 100 2\r\n",
+			response:  []byte("scanraw 0 100 2\r\n{x\x20\x09x\x0d}ch> "),
+			expectErr: true,
+		},
+		{
+			name:      "scanraw response with missing record marker",
+			fullCmd:   "scanraw 0 100 2\r\n",
+			response:  []byte("scanraw 0 100 2\r\n{x\x20\x09\x0d\x0d\x0a}ch> "),
+			expectErr: true,
+		},
+		{
+			name:     "sd_read response",
+			fullCmd:  "sd_read a.txt\r\n",
+			response: []byte("sd_read a.txt\r\n\x07\x00\x00\x00ch> abcch> "),
+			want:     []byte("ch> abc"),
+		},
+		{
+			name:     "sd_read empty file",
+			fullCmd:  "sd_read a.txt\r\n",
+			response: []byte("sd_read a.txt\r\n\x00\x00\x00\x00ch> "),
+			want:     []byte(""),
+		},
+		{
+			name:      "sd_read error",
+			fullCmd:   "sd_read a.txt\r\n",
+			response:  []byte("sd_read a.txt\r\nerr: no file\r\nch> "),
+			expectErr: true,
+		},
+		{
+			name:      "sd_read response with wrong length",
+			fullCmd:   "sd_read a.txt\r\n",
+			response:  []byte("sd_read a.txt\r\n\x09\x00\x00\x00abcch> "),
+			expectErr: true,
+		},
 		{
 			name:      "echo command received, missing command prompt",
 			fullCmd:   "sweep start 120000000\r\n",
@@ -65,3 +113,27 @@ func TestHandleResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		fullCmd  string
+		response []byte
+		want     bool
+	}{
+		{name: "string response", fullCmd: "vbat\r\n", response: []byte("vbat\r\n4179 mV\r\nch> "), want: true},
+		{name: "string response incomplete", fullCmd: "vbat\r\n", response: []byte("vbat\r\n4179 mV\r\n")},
+		{name: "sd_read echo only", fullCmd: "sd_read a\r\n", response: []byte("sd_read a\r\n")},
+		{name: "sd_read prompt in data", fullCmd: "sd_read a\r\n", response: []byte("sd_read a\r\n\x07\x00\x00\x00ch> ")},
+		{name: "sd_read complete", fullCmd: "sd_read a\r\n", response: []byte("sd_read a\r\n\x07\x00\x00\x00ch> abcch> "), want: true},
+		{name: "sd_read error", fullCmd: "sd_read a\r\n", response: []byte("sd_read a\r\nerr: no file\r\nch> "), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := responseComplete(tt.fullCmd, tt.response); got != tt.want {
+				t.Errorf("responseComplete(%q) = %v, want %v", tt.response, got, tt.want)
+			}
+		})
+	}
+}
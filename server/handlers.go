@@ -0,0 +1,199 @@
+package server
+
+import (
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"strconv"
+)
+
+// sweepJSON is the JSON representation of the sweep settings.
+type sweepJSON struct {
+	Start  uint64 `json:"start"`
+	Stop   uint64 `json:"stop"`
+	Points uint   `json:"points"`
+}
+
+// sweepUpdateJSON is the JSON body of a sweep update, fields which are not set keep their value.
+type sweepUpdateJSON struct {
+	Start  *uint64 `json:"start"`
+	Stop   *uint64 `json:"stop"`
+	Points *uint   `json:"points"`
+}
+
+// traceDataJSON is the JSON representation of a trace data point.
+type traceDataJSON struct {
+	Trace     uint    `json:"trace"`
+	Point     uint    `json:"point"`
+	Frequency uint64  `json:"frequency"`
+	Value     float64 `json:"value"`
+}
+
+// markerJSON is the JSON representation of a marker.
+type markerJSON struct {
+	Marker    uint    `json:"marker"`
+	Index     uint    `json:"index"`
+	Frequency uint64  `json:"frequency"`
+	Value     float64 `json:"value"`
+}
+
+// batteryJSON is the JSON representation of the battery status.
+type batteryJSON struct {
+	Voltage uint `json:"voltage_mv"`
+}
+
+// pathID parses the path value name as id.
+func pathID(r *http.Request, name string) (uint, error) {
+	v := r.PathValue(name)
+	id, err := strconv.ParseUint(v, 10, 0)
+	if err != nil {
+		return 0, newArgumentError("invalid %s %q", name, v)
+	}
+	return uint(id), nil
+}
+
+func (s *Server) handleGetSweep(w http.ResponseWriter, r *http.Request) {
+	sweep, err := s.dev.GetSweepContext(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, sweepJSON(sweep))
+}
+
+func (s *Server) handlePutSweep(w http.ResponseWriter, r *http.Request) {
+	var update sweepUpdateJSON
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&update); err != nil {
+		s.writeError(w, r, newArgumentError("invalid sweep: %s", err.Error()))
+		return
+	}
+
+	s.sweepMutex.Lock()
+	defer s.sweepMutex.Unlock()
+
+	sweep, err := s.dev.GetSweepContext(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if update.Start != nil {
+		sweep.Start = *update.Start
+	}
+	if update.Stop != nil {
+		sweep.Stop = *update.Stop
+	}
+	if update.Points != nil {
+		sweep.Points = *update.Points
+	}
+	if sweep.Start > sweep.Stop {
+		s.writeError(w, r, newArgumentError("start frequency %d is above stop frequency %d", sweep.Start, sweep.Stop))
+		return
+	}
+	if sweep.Points == 0 {
+		s.writeError(w, r, newArgumentError("invalid number of points 0"))
+		return
+	}
+
+	if err = s.dev.SetSweepStartStopWithPointsContext(r.Context(), sweep.Start, sweep.Stop, sweep.Points); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	s.handleGetSweep(w, r)
+}
+
+func (s *Server) handleGetTrace(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	data, err := s.dev.GetTraceDataContext(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	out := make([]traceDataJSON, len(data))
+	for i, d := range data {
+		out[i] = traceDataJSON(d)
+	}
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleGetMarkers(w http.ResponseWriter, r *http.Request) {
+	markers, err := s.dev.GetMarkerAllContext(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	out := make([]markerJSON, len(markers))
+	for i, m := range markers {
+		out[i] = markerJSON(m)
+	}
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleGetMarker(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	marker, err := s.dev.GetMarkerContext(r.Context(), id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, markerJSON(marker))
+}
+
+func (s *Server) handlePreset(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	switch action := r.PathValue("action"); action {
+	case "load":
+		err = s.dev.LoadPresetContext(r.Context(), id)
+	case "save":
+		err = s.dev.SavePresetContext(r.Context(), id)
+	default:
+		err = newArgumentError("invalid preset action %q", action)
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleCapture(w http.ResponseWriter, r *http.Request) {
+	img, err := s.dev.CaptureContext(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	if err = png.Encode(w, img); err != nil {
+		s.logger.Warn("failed to write capture", "err", err)
+	}
+}
+
+func (s *Server) handleGetBattery(w http.ResponseWriter, r *http.Request) {
+	voltage, err := s.dev.GetBatteryVoltageContext(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, batteryJSON{Voltage: voltage})
+}
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kkettinger/go-tinysa"
+)
+
+// webFiles contains the browser UI of the live view.
+//
+//go:embed web
+var webFiles embed.FS
+
+const (
+	// liveClientBuffer is the number of frames buffered per client, older frames are dropped for slow clients.
+	liveClientBuffer = 4
+
+	// liveWriteTimeout is the maximum time to write a message to a client.
+	liveWriteTimeout = 5 * time.Second
+)
+
+// liveMessageJSON is a message sent to the live view clients.
+type liveMessageJSON struct {
+	Type        string    `json:"type"` // "frame" or "error"
+	Time        time.Time `json:"time,omitzero"`
+	Start       uint64    `json:"start"`
+	Stop        uint64    `json:"stop"`
+	Frequencies []uint64  `json:"frequencies,omitempty"`
+	Values      []float64 `json:"values,omitempty"`
+	Message     string    `json:"message,omitempty"`
+}
+
+// liveControlJSON is a control message received from a live view client.
+//
+// Types: "sweep" sets start and stop in Hz, "rbw" sets value in Hz, "reflevel" sets value in dBm and "scale" sets
+// value in dB per division. Auto selects the automatic setting of rbw and reflevel.
+type liveControlJSON struct {
+	Type  string  `json:"type"`
+	Start uint64  `json:"start"`
+	Stop  uint64  `json:"stop"`
+	Value float64 `json:"value"`
+	Auto  bool    `json:"auto"`
+}
+
+// liveClient is a connected live view client.
+type liveClient struct {
+	send chan []byte
+}
+
+// liveHub streams the sweeps of a device to all connected live view clients. The stream only runs while at least
+// one client is connected.
+type liveHub struct {
+	dev      *tinysa.Device
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	clients map[*liveClient]struct{}
+	cancel  context.CancelFunc
+}
+
+// newLiveHub creates a liveHub acquiring a sweep of trace 1 every interval.
+func newLiveHub(dev *tinysa.Device, interval time.Duration, logger *slog.Logger) *liveHub {
+	return &liveHub{
+		dev:      dev,
+		interval: interval,
+		logger:   logger,
+		clients:  make(map[*liveClient]struct{}),
+	}
+}
+
+// join registers a client and starts the stream for the first client.
+func (h *liveHub) join(c *liveClient) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.clients) == 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		frames, err := h.dev.Stream(ctx, tinysa.StreamOptions{
+			Trace:    1,
+			Interval: h.interval,
+			Buffer:   1,
+			Policy:   tinysa.StreamDropOldest,
+		})
+		if err != nil {
+			cancel()
+			return err
+		}
+		h.cancel = cancel
+		go h.broadcast(frames)
+		h.logger.Info("live stream started")
+	}
+
+	h.clients[c] = struct{}{}
+	return nil
+}
+
+// leave unregisters a client and stops the stream after the last client left.
+func (h *liveHub) leave(c *liveClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.clients, c)
+	if len(h.clients) == 0 && h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+		h.logger.Info("live stream stopped")
+	}
+}
+
+// broadcast sends every frame to all clients, until frames is closed.
+func (h *liveHub) broadcast(frames <-chan tinysa.SweepFrame) {
+	for frame := range frames {
+		msg := liveMessageJSON{Type: "frame", Time: frame.Time}
+		if frame.Err != nil {
+			msg = liveMessageJSON{Type: "error", Time: frame.Time, Message: frame.Err.Error()}
+		} else {
+			msg.Start, msg.Stop = frame.Sweep.Start, frame.Sweep.Stop
+			msg.Frequencies = make([]uint64, len(frame.Data))
+			msg.Values = make([]float64, len(frame.Data))
+			for i, d := range frame.Data {
+				msg.Frequencies[i], msg.Values[i] = d.Frequency, d.Value
+			}
+		}
+
+		data, err := json.Marshal(msg)
+		if err != nil {
+			h.logger.Error("failed to encode live frame", "err", err)
+			continue
+		}
+
+		h.mu.Lock()
+		for c := range h.clients {
+			c.push(data)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// push queues a message for the client, dropping the oldest queued message if the client does not keep up.
+func (c *liveClient) push(data []byte) {
+	for {
+		select {
+		case c.send <- data:
+			return
+		default:
+			select {
+			case <-c.send:
+			default:
+			}
+		}
+	}
+}
+
+// handleLivePage serves the live view browser UI.
+func (s *Server) handleLivePage(w http.ResponseWriter, _ *http.Request) {
+	page, err := webFiles.ReadFile("web/live.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
+
+// handleLiveSocket streams sweep frames to a WebSocket client and applies its control messages.
+func (s *Server) handleLiveSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Info("websocket upgrade failed", "err", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	client := &liveClient{send: make(chan []byte, liveClientBuffer)}
+	if err = s.live.join(client); err != nil {
+		s.logger.Error("failed to start live stream", "err", err)
+		return
+	}
+	defer s.live.leave(client)
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.writeLive(conn, client, done)
+
+	for {
+		var ctrl liveControlJSON
+		if err = conn.ReadJSON(&ctrl); err != nil {
+			s.logger.Debug("live client disconnected", "err", err)
+			return
+		}
+		if err = s.applyLiveControl(r.Context(), ctrl); err != nil {
+			s.logger.Info("live control failed", "control", ctrl, "err", err)
+			data, _ := json.Marshal(liveMessageJSON{Type: "error", Time: time.Now(), Message: err.Error()})
+			client.push(data)
+		}
+	}
+}
+
+// writeLive writes the queued messages of client to conn, until done is closed or writing fails.
+func (s *Server) writeLive(conn *websocket.Conn, client *liveClient, done <-chan struct{}) {
+	for {
+		select {
+		case data := <-client.send:
+			_ = conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				s.logger.Debug("failed to write to live client", "err", err)
+				_ = conn.Close()
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// applyLiveControl applies a control message of a live view client to the device.
+func (s *Server) applyLiveControl(ctx context.Context, ctrl liveControlJSON) error {
+	switch ctrl.Type {
+	case "sweep":
+		if ctrl.Start > ctrl.Stop {
+			return fmt.Errorf("start frequency %d is above stop frequency %d", ctrl.Start, ctrl.Stop)
+		}
+		s.sweepMutex.Lock()
+		defer s.sweepMutex.Unlock()
+		return s.dev.SetSweepStartStopContext(ctx, ctrl.Start, ctrl.Stop)
+	case "rbw":
+		if ctrl.Auto {
+			return s.dev.SetRBWAutoContext(ctx)
+		}
+		return s.dev.SetRBWContext(ctx, uint64(ctrl.Value))
+	case "reflevel":
+		if ctrl.Auto {
+			return s.dev.SetTraceRefLevelAutoContext(ctx)
+		}
+		return s.dev.SetTraceRefLevelContext(ctx, int(ctrl.Value))
+	case "scale":
+		return s.dev.SetTraceScaleContext(ctx, ctrl.Value)
+	default:
+		return fmt.Errorf("unknown control %q", ctrl.Type)
+	}
+}
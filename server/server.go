@@ -0,0 +1,184 @@
+// Package server exposes a tinysa.Device as HTTP/JSON REST API, so several users can share one device.
+//
+// All requests are serialised by the command lock of the device; requests running at the same time are executed one
+// command after another. Sweep updates additionally hold a server lock while reading and writing the settings, so
+// concurrent partial updates do not overwrite each other. Errors are returned as JSON object with an HTTP status code matching the cause:
+//
+//	{"error": {"code": "invalid_argument", "message": "invalid trace id \"x\""}}
+//
+// Endpoints:
+//
+//	GET  /sweep                 current sweep settings
+//	PUT  /sweep                 set the sweep settings, fields not given are kept
+//	GET  /traces/{id}           trace data of a trace
+//	GET  /markers               all enabled markers
+//	GET  /markers/{id}          a single marker
+//	POST /presets/{id}/load     load a preset
+//	POST /presets/{id}/save     save the current settings as preset
+//	GET  /capture.png           screenshot as PNG image
+//	GET  /battery               battery voltage
+//	GET  /live                  browser UI with live trace, max-hold and waterfall
+//	GET  /live/ws               WebSocket streaming the sweeps of trace 1 and accepting control messages
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kkettinger/go-tinysa"
+)
+
+// defaultLiveInterval is the default minimum time between two sweeps sent to live view clients.
+const defaultLiveInterval = 500 * time.Millisecond
+
+// Error codes of the JSON error responses.
+const (
+	CodeInvalidArgument = "invalid_argument" // The request contains invalid parameters
+	CodeNotSupported    = "not_supported"    // The request is not supported by the device model
+	CodeTimeout         = "timeout"          // The device did not respond in time
+	CodeCancelled       = "cancelled"        // The request was cancelled by the client
+	CodeDeviceError     = "device_error"     // The device command failed
+)
+
+// Server serves the REST API of a device. It implements http.Handler.
+type Server struct {
+	dev      *tinysa.Device
+	mux      *http.ServeMux
+	logger   *slog.Logger
+	live     *liveHub
+	upgrader websocket.Upgrader
+
+	// sweepMutex serializes sweep updates, which read the current settings before changing them
+	sweepMutex sync.Mutex
+}
+
+type options struct {
+	logger       *slog.Logger
+	liveInterval time.Duration
+}
+
+// Option defines a function type that modifies the server options.
+type Option func(*options)
+
+// WithLogger sets a custom slog.Logger for logging requests and errors.
+func WithLogger(logger *slog.Logger) Option {
+	return func(opts *options) {
+		opts.logger = logger
+	}
+}
+
+// WithLiveInterval sets the minimum time between two sweeps sent to live view clients. Defaults to 500ms, 0 sends
+// sweeps back to back.
+func WithLiveInterval(interval time.Duration) Option {
+	return func(opts *options) {
+		opts.liveInterval = interval
+	}
+}
+
+// New creates a new Server for the given device.
+func New(dev *tinysa.Device, opts ...Option) *Server {
+	options := options{
+		logger:       slog.New(slog.DiscardHandler),
+		liveInterval: defaultLiveInterval,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	s := &Server{
+		dev:    dev,
+		mux:    http.NewServeMux(),
+		logger: options.logger,
+		live:   newLiveHub(dev, options.liveInterval, options.logger),
+	}
+	s.routes()
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Handle registers an additional handler on the server mux, e.g. to extend the API with custom endpoints.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// routes registers all API endpoints.
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /sweep", s.handleGetSweep)
+	s.mux.HandleFunc("PUT /sweep", s.handlePutSweep)
+	s.mux.HandleFunc("GET /traces/{id}", s.handleGetTrace)
+	s.mux.HandleFunc("GET /markers", s.handleGetMarkers)
+	s.mux.HandleFunc("GET /markers/{id}", s.handleGetMarker)
+	s.mux.HandleFunc("POST /presets/{id}/{action}", s.handlePreset)
+	s.mux.HandleFunc("GET /capture.png", s.handleCapture)
+	s.mux.HandleFunc("GET /battery", s.handleGetBattery)
+	s.mux.HandleFunc("GET /live", s.handleLivePage)
+	s.mux.HandleFunc("GET /live/ws", s.handleLiveSocket)
+}
+
+// errorBody is the JSON body of an error response.
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+// errorDetail describes an error of a request.
+type errorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// argumentError is returned by handlers for invalid request parameters.
+type argumentError struct {
+	msg string
+}
+
+func (e argumentError) Error() string {
+	return e.msg
+}
+
+// newArgumentError returns an argumentError with a formatted message.
+func newArgumentError(format string, args ...any) error {
+	return argumentError{msg: fmt.Sprintf(format, args...)}
+}
+
+// writeJSON writes v as JSON response with the given status code.
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Warn("failed to write response", "err", err)
+	}
+}
+
+// writeError writes err as JSON error response, with status and code selected by the cause of err.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := http.StatusBadGateway, CodeDeviceError
+
+	var argErr argumentError
+	switch {
+	case errors.As(err, &argErr):
+		status, code = http.StatusBadRequest, CodeInvalidArgument
+	case errors.Is(err, tinysa.ErrValueOutOfRange):
+		status, code = http.StatusBadRequest, CodeInvalidArgument
+	case errors.Is(err, tinysa.ErrOptionNotSupportedByModel):
+		status, code = http.StatusUnprocessableEntity, CodeNotSupported
+	case errors.Is(err, tinysa.ErrCommandResponseTimeout), errors.Is(err, context.DeadlineExceeded):
+		status, code = http.StatusGatewayTimeout, CodeTimeout
+	case errors.Is(err, context.Canceled):
+		status, code = http.StatusServiceUnavailable, CodeCancelled
+	}
+
+	s.logger.Info("request failed", "method", r.Method, "path", r.URL.Path, "status", status, "err", err)
+	s.writeJSON(w, status, errorBody{Error: errorDetail{Code: code, Message: err.Error()}})
+}
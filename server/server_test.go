@@ -0,0 +1,318 @@
+package server
+
+import (
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kkettinger/go-tinysa"
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+// newTestServer creates a test HTTP server for a device connected to a simulator of the given model.
+func newTestServer(t *testing.T, model tinysatest.Model) (*httptest.Server, *tinysatest.Simulator) {
+	t.Helper()
+
+	sim := tinysatest.New(model)
+	dev, err := tinysa.NewDeviceFromTransport(sim, tinysa.WithReadTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create device: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = dev.Close() })
+
+	ts := httptest.NewServer(New(dev))
+	t.Cleanup(ts.Close)
+
+	return ts, sim
+}
+
+// doRequest sends a request and decodes the JSON response into v, if v is not nil.
+func doRequest(t *testing.T, method string, url string, body string, v any) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if v != nil {
+		if err = json.NewDecoder(res.Body).Decode(v); err != nil {
+			t.Fatalf("failed to decode response: %s", err.Error())
+		}
+	}
+	return res
+}
+
+func TestSweep(t *testing.T) {
+	ts, sim := newTestServer(t, tinysatest.ModelUltra)
+
+	var sweep sweepJSON
+	res := doRequest(t, http.MethodGet, ts.URL+"/sweep", "", &sweep)
+	if res.StatusCode != http.StatusOK || sweep != (sweepJSON{0, 800e6, 450}) {
+		t.Errorf("GET /sweep = %d %+v", res.StatusCode, sweep)
+	}
+
+	res = doRequest(t, http.MethodPut, ts.URL+"/sweep", `{"start": 100000000, "points": 101}`, &sweep)
+	if res.StatusCode != http.StatusOK || sweep != (sweepJSON{100e6, 800e6, 101}) {
+		t.Errorf("PUT /sweep = %d %+v", res.StatusCode, sweep)
+	}
+	if !slices.Contains(sim.Commands(), "sweep 100000000 800000000 101") {
+		t.Errorf("sweep command not sent, got %q", sim.Commands())
+	}
+}
+
+func TestConcurrentSweepUpdates(t *testing.T) {
+	ts, sim := newTestServer(t, tinysatest.ModelUltra)
+
+	// A slow sweep command lets the requests queue up on the device, so their commands interleave.
+	var mu sync.Mutex
+	settings := []string{"0", "800000000", "450"}
+	sim.Handle("sweep", func(args []string) []byte {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		if len(args) == 3 {
+			copy(settings, args)
+			return nil
+		}
+		return []byte(strings.Join(settings, " ") + "\r\n")
+	})
+
+	// Every update changes a different field, none of them may be lost.
+	updates := []string{`{"start": 100000000}`, `{"stop": 300000000}`, `{"points": 101}`}
+	var wg sync.WaitGroup
+	for range 4 {
+		for _, body := range updates {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if res := doRequest(t, http.MethodPut, ts.URL+"/sweep", body, nil); res.StatusCode != http.StatusOK {
+					t.Errorf("PUT /sweep %s = %d", body, res.StatusCode)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	var sweep sweepJSON
+	doRequest(t, http.MethodGet, ts.URL+"/sweep", "", &sweep)
+	if want := (sweepJSON{100e6, 300e6, 101}); sweep != want {
+		t.Errorf("GET /sweep after concurrent updates = %+v, want %+v", sweep, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ts, _ := newTestServer(t, tinysatest.ModelUltra)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{name: "invalid trace id", method: http.MethodGet, path: "/traces/x", status: http.StatusBadRequest, code: CodeInvalidArgument},
+		{name: "invalid sweep json", method: http.MethodPut, path: "/sweep", body: "{", status: http.StatusBadRequest, code: CodeInvalidArgument},
+		{name: "unknown sweep field", method: http.MethodPut, path: "/sweep", body: `{"span": 1}`, status: http.StatusBadRequest, code: CodeInvalidArgument},
+		{name: "start above stop", method: http.MethodPut, path: "/sweep", body: `{"start": 900000000}`, status: http.StatusBadRequest, code: CodeInvalidArgument},
+		{name: "invalid preset action", method: http.MethodPost, path: "/presets/1/delete", status: http.StatusBadRequest, code: CodeInvalidArgument},
+		{name: "device error", method: http.MethodGet, path: "/traces/9", status: http.StatusBadGateway, code: CodeDeviceError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body errorBody
+			res := doRequest(t, tt.method, ts.URL+tt.path, tt.body, &body)
+			if res.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", res.StatusCode, tt.status)
+			}
+			if res.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("content type = %q, want application/json", res.Header.Get("Content-Type"))
+			}
+			if body.Error.Code != tt.code || body.Error.Message == "" {
+				t.Errorf("error = %+v, want code %q", body.Error, tt.code)
+			}
+		})
+	}
+}
+
+func TestTraceAndMarkers(t *testing.T) {
+	ts, _ := newTestServer(t, tinysatest.ModelBasic)
+
+	var data []traceDataJSON
+	res := doRequest(t, http.MethodGet, ts.URL+"/traces/1", "", &data)
+	if res.StatusCode != http.StatusOK || len(data) != 290 || data[289].Frequency != 350e6 {
+		t.Errorf("GET /traces/1 = %d, %d points", res.StatusCode, len(data))
+	}
+
+	var markers []markerJSON
+	res = doRequest(t, http.MethodGet, ts.URL+"/markers", "", &markers)
+	if res.StatusCode != http.StatusOK || len(markers) != 1 || markers[0].Marker != 1 {
+		t.Errorf("GET /markers = %d %+v", res.StatusCode, markers)
+	}
+
+	var marker markerJSON
+	res = doRequest(t, http.MethodGet, ts.URL+"/markers/1", "", &marker)
+	if res.StatusCode != http.StatusOK || marker != markers[0] {
+		t.Errorf("GET /markers/1 = %d %+v, want %+v", res.StatusCode, marker, markers[0])
+	}
+}
+
+func TestPresetAndBattery(t *testing.T) {
+	ts, sim := newTestServer(t, tinysatest.ModelUltra)
+
+	for _, action := range []string{"load", "save"} {
+		res := doRequest(t, http.MethodPost, ts.URL+"/presets/2/"+action, "", nil)
+		if res.StatusCode != http.StatusNoContent {
+			t.Errorf("POST /presets/2/%s = %d", action, res.StatusCode)
+		}
+		if !slices.Contains(sim.Commands(), action+" 2") {
+			t.Errorf("command %q not sent", action+" 2")
+		}
+	}
+
+	var battery batteryJSON
+	res := doRequest(t, http.MethodGet, ts.URL+"/battery", "", &battery)
+	if res.StatusCode != http.StatusOK || battery.Voltage != 4191 {
+		t.Errorf("GET /battery = %d %+v", res.StatusCode, battery)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	ts, _ := newTestServer(t, tinysatest.ModelUltra)
+
+	res, err := http.Get(ts.URL + "/capture.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("content type = %q, want image/png", res.Header.Get("Content-Type"))
+	}
+	img, err := png.Decode(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 480 || img.Bounds().Dy() != 320 {
+		t.Errorf("image bounds = %v, want 480x320", img.Bounds())
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	ts, _ := newTestServer(t, tinysatest.ModelUltra)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := "/battery"
+			if i%2 == 0 {
+				path = "/traces/1"
+			}
+			res, err := http.Get(ts.URL + path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_ = res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Errorf("GET %s = %d", path, res.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLivePage(t *testing.T) {
+	ts, _ := newTestServer(t, tinysatest.ModelBasic)
+
+	res, err := http.Get(ts.URL + "/live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET /live = %d %q, want 200 text/html", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+}
+
+func TestLiveSocket(t *testing.T) {
+	ts, sim := newTestServer(t, tinysatest.ModelBasic)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/live/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var frame liveMessageJSON
+	if err = conn.ReadJSON(&frame); err != nil {
+		t.Fatal(err)
+	}
+	if frame.Type != "frame" || len(frame.Values) == 0 || len(frame.Values) != len(frame.Frequencies) {
+		t.Fatalf("first message = %+v, want frame with values", frame)
+	}
+
+	controls := []struct {
+		ctrl liveControlJSON
+		want string
+	}{
+		{ctrl: liveControlJSON{Type: "sweep", Start: 1000000, Stop: 2000000}, want: "sweep 1000000 2000000"},
+		{ctrl: liveControlJSON{Type: "rbw", Auto: true}, want: "rbw auto"},
+		{ctrl: liveControlJSON{Type: "reflevel", Value: -20}, want: "trace reflevel -20"},
+		{ctrl: liveControlJSON{Type: "scale", Value: 5}, want: "trace scale 5.000"},
+	}
+	for _, c := range controls {
+		if err = conn.WriteJSON(c.ctrl); err != nil {
+			t.Fatal(err)
+		}
+		waitForCommand(t, sim, c.want)
+	}
+
+	if err = conn.WriteJSON(liveControlJSON{Type: "bogus"}); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var msg liveMessageJSON
+		if err = conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type == "error" {
+			if !strings.Contains(msg.Message, "unknown control") {
+				t.Errorf("error message = %q, want unknown control", msg.Message)
+			}
+			break
+		}
+	}
+}
+
+// waitForCommand waits until the simulator received the given command.
+func waitForCommand(t *testing.T, sim *tinysatest.Simulator, want string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if slices.Contains(sim.Commands(), want) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("command %q not received, got %q", want, sim.Commands())
+}
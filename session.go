@@ -0,0 +1,235 @@
+package tinysa
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session files contain one entry per line: the direction (`>` for bytes sent to the device, `<` for bytes received
+// from it), an RFC 3339 timestamp and the exact bytes as Go quoted string. Lines starting with `#` are comments.
+//
+// Example session:
+//
+//	# go-tinysa session v1
+//	> 2025-04-12T10:15:02.120519Z "vbat\r\n"
+//	< 2025-04-12T10:15:02.123001Z "vbat\r\n4191 mV\r\nch> "
+const (
+	sessionHeader = "# go-tinysa session v1"
+	sessionSent   = '>'
+	sessionRecv   = '<'
+)
+
+// ErrReplayMismatch is returned by a ReplayTransport if a written command differs from the recorded one.
+var ErrReplayMismatch = errors.New("command does not match recorded session")
+
+// sessionEntry is a single recorded chunk of bytes.
+type sessionEntry struct {
+	dir  byte
+	time time.Time
+	data []byte
+}
+
+// String formats the entry as a session file line.
+func (e sessionEntry) String() string {
+	return fmt.Sprintf("%c %s %s", e.dir, e.time.UTC().Format(time.RFC3339Nano), strconv.Quote(string(e.data)))
+}
+
+// parseSessionLine parses a single session file line into a sessionEntry.
+//
+// Example line: `> 2025-04-12T10:15:02.120519Z "vbat\r\n"`
+func parseSessionLine(line string) (sessionEntry, error) {
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) != 3 {
+		return sessionEntry{}, fmt.Errorf("expected 3 fields, got %d", len(parts))
+	}
+
+	if len(parts[0]) != 1 || (parts[0][0] != sessionSent && parts[0][0] != sessionRecv) {
+		return sessionEntry{}, fmt.Errorf("invalid direction %q", parts[0])
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return sessionEntry{}, fmt.Errorf("invalid timestamp %q: %s", parts[1], err.Error())
+	}
+
+	data, err := strconv.Unquote(parts[2])
+	if err != nil {
+		return sessionEntry{}, fmt.Errorf("invalid data %s: %s", parts[2], err.Error())
+	}
+
+	return sessionEntry{dir: parts[0][0], time: ts, data: []byte(data)}, nil
+}
+
+// readSession reads all entries of a session file.
+func readSession(r io.Reader) ([]sessionEntry, error) {
+	var entries []sessionEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, err := parseSessionLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNo, err.Error())
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session: %s", err.Error())
+	}
+
+	return entries, nil
+}
+
+// recordingTransport wraps a Transport and writes all sent and received bytes to a session file.
+type recordingTransport struct {
+	Transport
+	mutex  sync.Mutex
+	w      io.Writer
+	logger *slog.Logger
+}
+
+// newRecordingTransport wraps t, recording the session to w.
+func newRecordingTransport(t Transport, w io.Writer, logger *slog.Logger) *recordingTransport {
+	r := &recordingTransport{Transport: t, w: w, logger: logger}
+	if _, err := fmt.Fprintln(w, sessionHeader); err != nil {
+		logger.Warn("failed to write session header", "err", err)
+	}
+	return r
+}
+
+// record writes a session entry. Errors are logged, but never interrupt the communication with the device.
+func (r *recordingTransport) record(dir byte, data []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry := sessionEntry{dir: dir, time: time.Now(), data: data}
+	if _, err := fmt.Fprintln(r.w, entry.String()); err != nil {
+		r.logger.Warn("failed to record session entry", "err", err)
+	}
+}
+
+// Read reads from the wrapped transport and records the received bytes.
+func (r *recordingTransport) Read(p []byte) (int, error) {
+	n, err := r.Transport.Read(p)
+	if n > 0 {
+		r.record(sessionRecv, p[:n])
+	}
+	return n, err
+}
+
+// Write records the bytes and writes them to the wrapped transport.
+func (r *recordingTransport) Write(p []byte) (int, error) {
+	r.record(sessionSent, p)
+	return r.Transport.Write(p)
+}
+
+// ReplayTransport is a Transport that plays back a session recorded with WithRecorder. Every write must match the
+// next recorded command, after which the recorded response is returned by Read. Timing is not reproduced.
+type ReplayTransport struct {
+	mutex       sync.Mutex
+	entries     []sessionEntry
+	pos         int
+	pending     []byte
+	readTimeout time.Duration
+	closed      bool
+}
+
+// NewReplayTransport creates a ReplayTransport from a session file.
+func NewReplayTransport(r io.Reader) (*ReplayTransport, error) {
+	entries, err := readSession(r)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayTransport{entries: entries, readTimeout: -1}, nil
+}
+
+// Read returns the recorded response bytes. If the next recorded entry is a command, it waits for the read timeout
+// and returns 0 and a nil error, just like a silent serial port. At the end of the session io.EOF is returned.
+func (t *ReplayTransport) Read(p []byte) (int, error) {
+	t.mutex.Lock()
+	if t.closed {
+		t.mutex.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+
+	if len(t.pending) == 0 && t.pos < len(t.entries) && t.entries[t.pos].dir == sessionRecv {
+		t.pending = t.entries[t.pos].data
+		t.pos++
+	}
+
+	if len(t.pending) > 0 {
+		n := copy(p, t.pending)
+		t.pending = t.pending[n:]
+		t.mutex.Unlock()
+		return n, nil
+	}
+
+	exhausted := t.pos >= len(t.entries)
+	timeout := t.readTimeout
+	t.mutex.Unlock()
+
+	if exhausted || timeout < 0 {
+		return 0, io.EOF
+	}
+
+	time.Sleep(timeout)
+	return 0, nil
+}
+
+// Write checks p against the next recorded command. Recorded responses that were not read yet are kept, so they are
+// returned by the following reads in their original order.
+func (t *ReplayTransport) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	for t.pos < len(t.entries) && t.entries[t.pos].dir == sessionRecv {
+		t.pending = append(t.pending, t.entries[t.pos].data...)
+		t.pos++
+	}
+
+	if t.pos >= len(t.entries) {
+		return 0, fmt.Errorf("%w: got %q, session exhausted", ErrReplayMismatch, p)
+	}
+
+	want := t.entries[t.pos].data
+	if string(want) != string(p) {
+		return 0, fmt.Errorf("%w: got %q, want %q", ErrReplayMismatch, p, want)
+	}
+	t.pos++
+
+	return len(p), nil
+}
+
+// Close closes the transport.
+func (t *ReplayTransport) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.closed = true
+	return nil
+}
+
+// SetReadTimeout sets the time Read waits if no recorded response is available. A negative value disables waiting.
+func (t *ReplayTransport) SetReadTimeout(timeout time.Duration) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.readTimeout = timeout
+	return nil
+}
@@ -0,0 +1,130 @@
+package tinysa
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+func TestParseSessionLine(t *testing.T) {
+	ts := time.Date(2025, 4, 12, 10, 15, 2, 120519000, time.UTC)
+
+	tests := []struct {
+		name      string
+		input     string
+		want      sessionEntry
+		shouldErr bool
+	}{
+		{
+			name:  "sent command",
+			input: `> 2025-04-12T10:15:02.120519Z "vbat\r\n"`,
+			want:  sessionEntry{dir: '>', time: ts, data: []byte("vbat\r\n")},
+		},
+		{
+			name:  "received binary",
+			input: `< 2025-04-12T10:15:02.120519Z "\x00\xf8 ch> "`,
+			want:  sessionEntry{dir: '<', time: ts, data: []byte("\x00\xf8 ch> ")},
+		},
+		{name: "invalid direction", input: `? 2025-04-12T10:15:02.120519Z "vbat"`, shouldErr: true},
+		{name: "invalid timestamp", input: `> yesterday "vbat"`, shouldErr: true},
+		{name: "unquoted data", input: `> 2025-04-12T10:15:02.120519Z vbat`, shouldErr: true},
+		{name: "too few fields", input: `> "vbat"`, shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSessionLine(tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("parseSessionLine(%q) error = %v, wantErr = %v", tt.input, err, tt.shouldErr)
+			}
+			if !tt.shouldErr && (got.dir != tt.want.dir || !got.time.Equal(tt.want.time) || !bytes.Equal(got.data, tt.want.data)) {
+				t.Errorf("parseSessionLine(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if !tt.shouldErr && got.String() != tt.input {
+				t.Errorf("String() = %q, want %q", got.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var session bytes.Buffer
+
+	sim := tinysatest.New(tinysatest.ModelUltra)
+	dev, err := NewDeviceFromTransport(sim, WithReadTimeout(10*time.Millisecond), WithRecorder(&session))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSweep, err := dev.GetSweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantData, err := dev.GetTraceData(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantImg, err := dev.Capture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = dev.Close()
+
+	if !strings.HasPrefix(session.String(), sessionHeader+"\n") {
+		t.Fatalf("session does not start with header: %q", session.String()[:40])
+	}
+
+	replay, err := NewReplayTransport(&session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err = NewDeviceFromTransport(replay, WithReadTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	if dev.Model() != ModelUltra || dev.Version() != "1.4-197-gaa78ccc" {
+		t.Errorf("replayed probe returned model %s version %s", dev.Model(), dev.Version())
+	}
+
+	gotSweep, err := dev.GetSweep()
+	if err != nil || gotSweep != wantSweep {
+		t.Errorf("GetSweep() = %+v, %v, want %+v", gotSweep, err, wantSweep)
+	}
+	gotData, err := dev.GetTraceData(1)
+	if err != nil || !reflect.DeepEqual(gotData, wantData) {
+		t.Errorf("GetTraceData() differs from recording, err = %v", err)
+	}
+	gotImg, err := dev.Capture()
+	if err != nil || !reflect.DeepEqual(gotImg, wantImg) {
+		t.Errorf("Capture() differs from recording, err = %v", err)
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	session := sessionHeader + "\n" +
+		`> 2025-04-12T10:15:02.120519Z "version\r\n"` + "\n" +
+		`< 2025-04-12T10:15:02.123001Z "version\r\ntinySA4_v1.4-197-gaa78ccc\r\nHW Version:V0.4.5.1\r\nch> "` + "\n" +
+		`> 2025-04-12T10:15:03.000000Z "vbat\r\n"` + "\n" +
+		`< 2025-04-12T10:15:03.002000Z "vbat\r\n4191 mV\r\nch> "` + "\n"
+
+	replay, err := NewReplayTransport(strings.NewReader(session))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := NewDeviceFromTransport(replay, WithReadTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dev.Close()
+
+	if _, err = dev.GetSweep(); !errors.Is(err, ErrReplayMismatch) {
+		t.Errorf("expected ErrReplayMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package tinysa
+
+import (
+	"context"
+	"fmt"
+)
+
+// Attenuation represents the input attenuator setting of the device.
+type Attenuation struct {
+	Auto  bool // Attenuation is selected automatically
+	Level uint // Attenuation in dB, if not Auto
+}
+
+// GetAttenuation returns the current input attenuator setting.
+func (d *Device) GetAttenuation() (Attenuation, error) {
+	return d.GetAttenuationContext(context.Background())
+}
+
+// GetAttenuationContext is like GetAttenuation but uses ctx to cancel the command.
+func (d *Device) GetAttenuationContext(ctx context.Context) (Attenuation, error) {
+	d.logger.Info("requesting attenuation")
+
+	res, err := d.sendCommand(ctx, "attenuate")
+	if err != nil {
+		return Attenuation{}, err
+	}
+
+	atten, err := parseAttenuationResponse(res)
+	if err != nil {
+		d.logger.Error("failed to parse attenuation response", "response", res, "err", err)
+		return Attenuation{}, fmt.Errorf("failed to parse attenuation response: %s", err.Error())
+	}
+
+	return atten, nil
+}
+
+// SetAttenuation sets the input attenuator to the given level in dB. Levels above the maximum of the device model
+// return an error wrapping ErrValueOutOfRange.
+func (d *Device) SetAttenuation(levelDb uint) error {
+	return d.SetAttenuationContext(context.Background(), levelDb)
+}
+
+// SetAttenuationContext is like SetAttenuation but uses ctx to cancel the command.
+func (d *Device) SetAttenuationContext(ctx context.Context, levelDb uint) error {
+	d.logger.Info("setting attenuation", "level", levelDb)
+
+	if levelDb > d.maxAtten {
+		return fmt.Errorf("attenuation %d dB (supported: 0..%d): %w", levelDb, d.maxAtten, ErrValueOutOfRange)
+	}
+
+	return d.sendSetCommand(ctx, fmt.Sprintf("attenuate %d", levelDb))
+}
+
+// SetAttenuationAuto lets the device select the input attenuation based on the reference level.
+func (d *Device) SetAttenuationAuto() error {
+	return d.SetAttenuationAutoContext(context.Background())
+}
+
+// SetAttenuationAutoContext is like SetAttenuationAuto but uses ctx to cancel the command.
+func (d *Device) SetAttenuationAutoContext(ctx context.Context) error {
+	d.logger.Info("setting attenuation auto")
+	return d.sendSetCommand(ctx, "attenuate auto")
+}
@@ -1,15 +1,21 @@
 package tinysa
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 )
 
 // GetBatteryVoltage returns battery voltage in mV.
 func (d *Device) GetBatteryVoltage() (uint, error) {
+	return d.GetBatteryVoltageContext(context.Background())
+}
+
+// GetBatteryVoltageContext is like GetBatteryVoltage but uses ctx to cancel the command.
+func (d *Device) GetBatteryVoltageContext(ctx context.Context) (uint, error) {
 	d.logger.Info("retrieving battery voltage")
 
-	line, err := d.sendCommand("vbat")
+	line, err := d.sendCommand(ctx, "vbat")
 	if err != nil {
 		return 0, err
 	}
@@ -25,9 +31,14 @@ func (d *Device) GetBatteryVoltage() (uint, error) {
 
 // GetBatteryOffsetVoltage gets battery offset voltage in mV.
 func (d *Device) GetBatteryOffsetVoltage() (uint, error) {
+	return d.GetBatteryOffsetVoltageContext(context.Background())
+}
+
+// GetBatteryOffsetVoltageContext is like GetBatteryOffsetVoltage but uses ctx to cancel the command.
+func (d *Device) GetBatteryOffsetVoltageContext(ctx context.Context) (uint, error) {
 	d.logger.Info("retrieving battery voltage")
 
-	res, err := d.sendCommand("vbat_offset")
+	res, err := d.sendCommand(ctx, "vbat_offset")
 	if err != nil {
 		return 0, err
 	}
@@ -42,7 +53,11 @@ func (d *Device) GetBatteryOffsetVoltage() (uint, error) {
 
 // SetBatteryOffsetVoltage sets battery offset voltage in mV.
 func (d *Device) SetBatteryOffsetVoltage(voltage uint) error {
+	return d.SetBatteryOffsetVoltageContext(context.Background(), voltage)
+}
+
+// SetBatteryOffsetVoltageContext is like SetBatteryOffsetVoltage but uses ctx to cancel the command.
+func (d *Device) SetBatteryOffsetVoltageContext(ctx context.Context, voltage uint) error {
 	d.logger.Info("setting battery voltage", "voltage", voltage)
-	_, err := d.sendCommand(fmt.Sprintf("vbat_offset %d", voltage))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("vbat_offset %d", voltage))
 }
@@ -1,6 +1,7 @@
 package tinysa
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/color"
@@ -8,9 +9,14 @@ import (
 
 // Capture returns the current screen of the device as image.Image type.
 func (d *Device) Capture() (image.Image, error) {
+	return d.CaptureContext(context.Background())
+}
+
+// CaptureContext is like Capture but uses ctx to cancel the command.
+func (d *Device) CaptureContext(ctx context.Context) (image.Image, error) {
 	d.logger.Info("capturing image")
 
-	imgRaw, err := d.sendCommandBinary("capture")
+	imgRaw, err := d.sendCommandBinary(ctx, "capture")
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package tinysa
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -8,28 +9,43 @@ import (
 
 // GetVersion requests and returns the full version information.
 func (d *Device) GetVersion() (string, error) {
+	return d.GetVersionContext(context.Background())
+}
+
+// GetVersionContext is like GetVersion but uses ctx to cancel the command.
+func (d *Device) GetVersionContext(ctx context.Context) (string, error) {
 	d.logger.Info("requesting device version")
-	return d.sendCommand("version")
+	return d.sendCommand(ctx, "version")
 }
 
-// Reset restarts the device, optionally entering DFU mode (supported only by the basic model).
+// Reset restarts the device, optionally entering DFU mode. DFU mode is only supported by the basic model, the ultra
+// model returns an error wrapping ErrOptionNotSupportedByModel.
 func (d *Device) Reset(dfu bool) error {
+	return d.ResetContext(context.Background(), dfu)
+}
+
+// ResetContext is like Reset but uses ctx to cancel the command.
+func (d *Device) ResetContext(ctx context.Context, dfu bool) error {
 	d.logger.Info("resetting device", "dfu", dfu)
 	cmd := "reset"
 	if dfu {
-		if d.model != ModelBasic {
-			return fmt.Errorf("option `dfu` not supported by model %s", d.model)
+		if err := d.checkCapability(CapabilityResetDFU); err != nil {
+			return err
 		}
 		cmd += " dfu"
 	}
-	_, err := d.sendCommand(cmd)
-	return err
+	return d.sendSetCommand(ctx, cmd)
 }
 
 // GetDeviceID returns the device id.
 func (d *Device) GetDeviceID() (uint, error) {
+	return d.GetDeviceIDContext(context.Background())
+}
+
+// GetDeviceIDContext is like GetDeviceID but uses ctx to cancel the command.
+func (d *Device) GetDeviceIDContext(ctx context.Context) (uint, error) {
 	d.logger.Info("requesting device id")
-	res, err := d.sendCommand("deviceid")
+	res, err := d.sendCommand(ctx, "deviceid")
 	if err != nil {
 		return 0, fmt.Errorf("failed to get device id: %s", err.Error())
 	}
@@ -42,12 +58,21 @@ func (d *Device) GetDeviceID() (uint, error) {
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse device id: %s", err.Error())
 	}
+	d.setCachedDeviceID(uint(id))
 	return uint(id), nil
 }
 
 // SetDeviceID sets the device id.
 func (d *Device) SetDeviceID(id uint) error {
+	return d.SetDeviceIDContext(context.Background(), id)
+}
+
+// SetDeviceIDContext is like SetDeviceID but uses ctx to cancel the command.
+func (d *Device) SetDeviceIDContext(ctx context.Context, id uint) error {
 	d.logger.Info("setting device id", "id", id)
-	_, err := d.sendCommand(fmt.Sprintf("deviceid %d", id))
-	return err
+	if err := d.sendSetCommand(ctx, fmt.Sprintf("deviceid %d", id)); err != nil {
+		return err
+	}
+	d.setCachedDeviceID(id)
+	return nil
 }
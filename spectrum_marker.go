@@ -1,6 +1,7 @@
 package tinysa
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -15,9 +16,14 @@ type Marker struct {
 
 // GetMarker returns marker information for the given marker ID as Marker struct.
 func (d *Device) GetMarker(markerID uint) (Marker, error) {
+	return d.GetMarkerContext(context.Background(), markerID)
+}
+
+// GetMarkerContext is like GetMarker but uses ctx to cancel the command.
+func (d *Device) GetMarkerContext(ctx context.Context, markerID uint) (Marker, error) {
 	d.logger.Info("requesting marker information", "marker_id", markerID)
 
-	line, err := d.sendCommand(fmt.Sprintf("marker %d", markerID))
+	line, err := d.sendCommand(ctx, fmt.Sprintf("marker %d", markerID))
 	if err != nil {
 		return Marker{}, err
 	}
@@ -33,9 +39,14 @@ func (d *Device) GetMarker(markerID uint) (Marker, error) {
 
 // GetMarkerAll requests all marker information and returns a Marker slice containing index, frequency, and power.
 func (d *Device) GetMarkerAll() ([]Marker, error) {
+	return d.GetMarkerAllContext(context.Background())
+}
+
+// GetMarkerAllContext is like GetMarkerAll but uses ctx to cancel the command.
+func (d *Device) GetMarkerAllContext(ctx context.Context) ([]Marker, error) {
 	d.logger.Info("requesting all marker information")
 
-	statusStr, err := d.sendCommand("marker")
+	statusStr, err := d.sendCommand(ctx, "marker")
 	if err != nil {
 		return nil, err
 	}
@@ -56,63 +67,99 @@ func (d *Device) GetMarkerAll() ([]Marker, error) {
 
 // EnableMarker enables the marker for the specified markerId.
 func (d *Device) EnableMarker(markerID uint) error {
+	return d.EnableMarkerContext(context.Background(), markerID)
+}
+
+// EnableMarkerContext is like EnableMarker but uses ctx to cancel the command.
+func (d *Device) EnableMarkerContext(ctx context.Context, markerID uint) error {
 	d.logger.Info("enabling marker", "marker_id", markerID)
-	_, err := d.sendCommand(fmt.Sprintf("marker %d on", markerID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("marker %d on", markerID))
 }
 
 // DisableMarker disables the marker for the specified markerId.
 func (d *Device) DisableMarker(markerID uint) error {
+	return d.DisableMarkerContext(context.Background(), markerID)
+}
+
+// DisableMarkerContext is like DisableMarker but uses ctx to cancel the command.
+func (d *Device) DisableMarkerContext(ctx context.Context, markerID uint) error {
 	d.logger.Info("disabling marker", "marker_id", markerID)
-	_, err := d.sendCommand(fmt.Sprintf("marker %d off", markerID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("marker %d off", markerID))
 }
 
 // SetMarkerFreq sets the marker to the specified frequency.
 func (d *Device) SetMarkerFreq(markerID uint, freqHz uint64) error {
+	return d.SetMarkerFreqContext(context.Background(), markerID, freqHz)
+}
+
+// SetMarkerFreqContext is like SetMarkerFreq but uses ctx to cancel the command.
+func (d *Device) SetMarkerFreqContext(ctx context.Context, markerID uint, freqHz uint64) error {
 	d.logger.Info("setting marker frequency", "marker_id", markerID, "freq", freqHz)
-	_, err := d.sendCommand(fmt.Sprintf("marker %d %d", markerID, freqHz))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("marker %d %d", markerID, freqHz))
 }
 
 // SetMarkerTrace assigns the specified marker to the specified trace.
 func (d *Device) SetMarkerTrace(markerID uint, traceID uint) error {
+	return d.SetMarkerTraceContext(context.Background(), markerID, traceID)
+}
+
+// SetMarkerTraceContext is like SetMarkerTrace but uses ctx to cancel the command.
+func (d *Device) SetMarkerTraceContext(ctx context.Context, markerID uint, traceID uint) error {
 	d.logger.Info("assigning marker to trace", "marker_id", markerID, "trace_id", traceID)
-	_, err := d.sendCommand(fmt.Sprintf("marker %d trace %d", markerID, traceID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("marker %d trace %d", markerID, traceID))
 }
 
 // MoveMarkerPeak moves the marker to the peak value of the assigned trace.
 func (d *Device) MoveMarkerPeak(markerID uint) error {
+	return d.MoveMarkerPeakContext(context.Background(), markerID)
+}
+
+// MoveMarkerPeakContext is like MoveMarkerPeak but uses ctx to cancel the command.
+func (d *Device) MoveMarkerPeakContext(ctx context.Context, markerID uint) error {
 	d.logger.Info("move marker peak", "marker_id", markerID)
-	_, err := d.sendCommand(fmt.Sprintf("marker %d peak", markerID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("marker %d peak", markerID))
 }
 
 // EnableMarkerDelta sets the specified marker to delta mode, referencing the specified marker.
 func (d *Device) EnableMarkerDelta(markerID uint, refMarkerID uint) error {
+	return d.EnableMarkerDeltaContext(context.Background(), markerID, refMarkerID)
+}
+
+// EnableMarkerDeltaContext is like EnableMarkerDelta but uses ctx to cancel the command.
+func (d *Device) EnableMarkerDeltaContext(ctx context.Context, markerID uint, refMarkerID uint) error {
 	d.logger.Info("enabling marker delta", "marker_id", markerID, "ref_marker_id", refMarkerID)
-	_, err := d.sendCommand(fmt.Sprintf("marker %d delta %d", markerID, refMarkerID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("marker %d delta %d", markerID, refMarkerID))
 }
 
 // DisableMarkerDelta disables delta mode for the specified marker.
 func (d *Device) DisableMarkerDelta(markerID uint) error {
+	return d.DisableMarkerDeltaContext(context.Background(), markerID)
+}
+
+// DisableMarkerDeltaContext is like DisableMarkerDelta but uses ctx to cancel the command.
+func (d *Device) DisableMarkerDeltaContext(ctx context.Context, markerID uint) error {
 	d.logger.Info("disabling marker delta", "marker_id", markerID)
-	_, err := d.sendCommand(fmt.Sprintf("marker %d delta off", markerID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("marker %d delta off", markerID))
 }
 
 // EnableMarkerTracking enables tracking of the peak value for the assigned trace of the given marker.
 func (d *Device) EnableMarkerTracking(markerID uint) error {
+	return d.EnableMarkerTrackingContext(context.Background(), markerID)
+}
+
+// EnableMarkerTrackingContext is like EnableMarkerTracking but uses ctx to cancel the command.
+func (d *Device) EnableMarkerTrackingContext(ctx context.Context, markerID uint) error {
 	d.logger.Info("enabling marker tracking", "marker_id", markerID)
-	_, err := d.sendCommand(fmt.Sprintf("marker %d tracking on", markerID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("marker %d tracking on", markerID))
 }
 
 // DisableMarkerTracking disables tracking of the peak value for the assigned trace of the given marker.
 func (d *Device) DisableMarkerTracking(markerID uint) error {
+	return d.DisableMarkerTrackingContext(context.Background(), markerID)
+}
+
+// DisableMarkerTrackingContext is like DisableMarkerTracking but uses ctx to cancel the command.
+func (d *Device) DisableMarkerTrackingContext(ctx context.Context, markerID uint) error {
 	d.logger.Info("disabling marker tracking", "marker_id", markerID)
-	_, err := d.sendCommand(fmt.Sprintf("marker %d tracking off", markerID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("marker %d tracking off", markerID))
 }
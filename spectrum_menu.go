@@ -1,6 +1,7 @@
 package tinysa
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -9,6 +10,11 @@ import (
 // TriggerMenu virtually clicks on the menu items on the device. First element starts with 1.
 // Example: [6, 4, 2] enables the waterfall display.
 func (d *Device) TriggerMenu(menuIDs []uint) error {
+	return d.TriggerMenuContext(context.Background(), menuIDs)
+}
+
+// TriggerMenuContext is like TriggerMenu but uses ctx to cancel the command.
+func (d *Device) TriggerMenuContext(ctx context.Context, menuIDs []uint) error {
 	d.logger.Info("triggering menu", "menu_ids", menuIDs)
 	strs := make([]string, len(menuIDs))
 	for i, v := range menuIDs {
@@ -18,6 +24,5 @@ func (d *Device) TriggerMenu(menuIDs []uint) error {
 		strs[i] = strconv.Itoa(int(v))
 	}
 	menuStr := strings.Join(strs, " ")
-	_, err := d.sendCommand(fmt.Sprintf("menu %s", menuStr))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("menu %s", menuStr))
 }
@@ -1,17 +1,28 @@
 package tinysa
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // LoadPreset loads a configuration from internal storage of the device.
 func (d *Device) LoadPreset(presetID uint) error {
+	return d.LoadPresetContext(context.Background(), presetID)
+}
+
+// LoadPresetContext is like LoadPreset but uses ctx to cancel the command.
+func (d *Device) LoadPresetContext(ctx context.Context, presetID uint) error {
 	d.logger.Info("loading preset", "preset_id", presetID)
-	_, err := d.sendCommand(fmt.Sprintf("load %d", presetID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("load %d", presetID))
 }
 
 // SavePreset saves the current configuration to the internal storage of the device.
 func (d *Device) SavePreset(presetID uint) error {
+	return d.SavePresetContext(context.Background(), presetID)
+}
+
+// SavePresetContext is like SavePreset but uses ctx to cancel the command.
+func (d *Device) SavePresetContext(ctx context.Context, presetID uint) error {
 	d.logger.Info("saving preset", "preset_id", presetID)
-	_, err := d.sendCommand(fmt.Sprintf("save %d", presetID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("save %d", presetID))
 }
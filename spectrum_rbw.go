@@ -0,0 +1,71 @@
+package tinysa
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// RBW represents the resolution bandwidth setting of the device.
+type RBW struct {
+	Auto      bool   // Resolution bandwidth is selected automatically
+	Frequency uint64 // Resolution bandwidth in Hz, 0 if not reported
+}
+
+// RBWSteps returns the resolution bandwidths in Hz supported by the detected device model.
+func (d *Device) RBWSteps() []uint64 {
+	return slices.Clone(d.rbwSteps)
+}
+
+// GetRBW returns the current resolution bandwidth setting.
+func (d *Device) GetRBW() (RBW, error) {
+	return d.GetRBWContext(context.Background())
+}
+
+// GetRBWContext is like GetRBW but uses ctx to cancel the command.
+func (d *Device) GetRBWContext(ctx context.Context) (RBW, error) {
+	d.logger.Info("requesting rbw")
+
+	res, err := d.sendCommand(ctx, "rbw")
+	if err != nil {
+		return RBW{}, err
+	}
+
+	rbw, err := parseRBWResponse(res)
+	if err != nil {
+		d.logger.Error("failed to parse rbw response", "response", res, "err", err)
+		return RBW{}, fmt.Errorf("failed to parse rbw response: %s", err.Error())
+	}
+
+	return rbw, nil
+}
+
+// SetRBW sets the resolution bandwidth in Hz. Only the discrete steps of the device model are supported (see
+// RBWSteps), other values return an error wrapping ErrOptionNotSupportedByModel.
+func (d *Device) SetRBW(rbwHz uint64) error {
+	return d.SetRBWContext(context.Background(), rbwHz)
+}
+
+// SetRBWContext is like SetRBW but uses ctx to cancel the command.
+func (d *Device) SetRBWContext(ctx context.Context, rbwHz uint64) error {
+	d.logger.Info("setting rbw", "rbw", rbwHz)
+
+	if !slices.Contains(d.rbwSteps, rbwHz) {
+		return fmt.Errorf("rbw %d Hz (supported: %v): %w", rbwHz, d.rbwSteps, ErrOptionNotSupportedByModel)
+	}
+
+	// The firmware expects the value in kHz.
+	return d.sendSetCommand(ctx, fmt.Sprintf("rbw %s", strconv.FormatFloat(float64(rbwHz)/1e3, 'f', -1, 64)))
+}
+
+// SetRBWAuto lets the device select the resolution bandwidth based on span and sweep points.
+func (d *Device) SetRBWAuto() error {
+	return d.SetRBWAutoContext(context.Background())
+}
+
+// SetRBWAutoContext is like SetRBWAuto but uses ctx to cancel the command.
+func (d *Device) SetRBWAutoContext(ctx context.Context) error {
+	d.logger.Info("setting rbw auto")
+	return d.sendSetCommand(ctx, "rbw auto")
+}
@@ -0,0 +1,133 @@
+package tinysa
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// ScanOutputs selects the columns returned by the `scan` command (the firmware outmask). Combine with bitwise or.
+type ScanOutputs uint
+
+const (
+	// ScanOutputFrequency returns the frequency in Hz of each point.
+	ScanOutputFrequency ScanOutputs = 1 << iota
+
+	// ScanOutputMeasured returns the measured level of each point.
+	ScanOutputMeasured
+
+	// ScanOutputStored returns the stored trace level of each point.
+	ScanOutputStored
+)
+
+// scanOutputsAll contains all valid ScanOutputs bits.
+const scanOutputsAll = ScanOutputFrequency | ScanOutputMeasured | ScanOutputStored
+
+// Has reports whether all outputs of o are selected.
+func (s ScanOutputs) Has(o ScanOutputs) bool {
+	return s&o == o
+}
+
+// ScanPoint is a single point of a scan. Only the fields selected by the ScanOutputs of the scan are set.
+type ScanPoint struct {
+	Point     uint    // Index of the point
+	Frequency uint64  // Frequency in Hz
+	Measured  float64 // Measured level
+	Stored    float64 // Stored trace level
+}
+
+// ScanResult contains the result of a single scan, together with the sweep and outputs it was taken with.
+type ScanResult struct {
+	Sweep   Sweep
+	Outputs ScanOutputs
+	Points  []ScanPoint
+}
+
+// ScanRaw performs a single sweep from start to stop frequency in Hz with the given number of points, using the
+// binary `scanraw` command. Frequencies and values come from the same sweep, so the result is always consistent.
+// The returned TraceData has the trace id 0, since the scan is not bound to a trace.
+//
+// Large point counts may take longer than the default response timeout, see WithResponseTimeout.
+func (d *Device) ScanRaw(start uint64, stop uint64, points uint) ([]TraceData, error) {
+	return d.ScanRawContext(context.Background(), start, stop, points)
+}
+
+// ScanRawContext is like ScanRaw but uses ctx to cancel the command.
+func (d *Device) ScanRawContext(ctx context.Context, start uint64, stop uint64, points uint) ([]TraceData, error) {
+	d.logger.Info("scanning raw", "start", start, "stop", stop, "points", points)
+
+	if points < 2 {
+		return nil, fmt.Errorf("invalid number of points %d, need at least 2", points)
+	}
+	if start > stop {
+		return nil, fmt.Errorf("start frequency %d is above stop frequency %d", start, stop)
+	}
+
+	values, err := d.sendCommandBinary(ctx, fmt.Sprintf("%s %d %d %d", scanRawCommand, start, stop, points))
+	if err != nil {
+		return nil, err
+	}
+
+	if uint(len(values)/2) != points {
+		d.logger.Error("unexpected number of scanraw points", "expected", points, "got", len(values)/2)
+		return nil, fmt.Errorf("expected %d scanraw points, got %d", points, len(values)/2)
+	}
+
+	data := make([]TraceData, points)
+	for i := range points {
+		raw := binary.LittleEndian.Uint16(values[i*2:])
+		data[i] = TraceData{
+			Point:     i,
+			Frequency: start + (stop-start)*uint64(i)/uint64(points-1),
+			Value:     float64(raw)/32 - d.levelOffset,
+		}
+	}
+
+	return data, nil
+}
+
+// Scan performs a single sweep with the `scan` command and returns the selected outputs of all points. Since all
+// columns come from one command, they are guaranteed to belong to the same sweep.
+func (d *Device) Scan(ctx context.Context, sweep Sweep, outputs ScanOutputs) (ScanResult, error) {
+	d.logger.Info("scanning", "sweep", sweep, "outputs", outputs)
+
+	if sweep.Points < 2 {
+		return ScanResult{}, fmt.Errorf("invalid number of points %d, need at least 2", sweep.Points)
+	}
+	if sweep.Start > sweep.Stop {
+		return ScanResult{}, fmt.Errorf("start frequency %d is above stop frequency %d", sweep.Start, sweep.Stop)
+	}
+	if outputs&^scanOutputsAll != 0 {
+		return ScanResult{}, fmt.Errorf("invalid scan outputs %d", outputs)
+	}
+
+	res, err := d.sendCommand(ctx, fmt.Sprintf("scan %d %d %d %d", sweep.Start, sweep.Stop, sweep.Points, outputs))
+	if err != nil {
+		return ScanResult{}, err
+	}
+
+	result := ScanResult{Sweep: sweep, Outputs: outputs}
+	if outputs == 0 {
+		return result, nil
+	}
+
+	lines := strings.Split(res, commandTerminator)
+	if uint(len(lines)) != sweep.Points {
+		d.logger.Error("unexpected number of scan points", "expected", sweep.Points, "got", len(lines))
+		return ScanResult{}, fmt.Errorf("expected %d scan points, got %d", sweep.Points, len(lines))
+	}
+
+	result.Points = make([]ScanPoint, len(lines))
+	for i, line := range lines {
+		p, err := parseScanResponseLine(line, outputs)
+		if err != nil {
+			d.logger.Error("failed to parse scan result", "line", line, "err", err)
+			return ScanResult{}, fmt.Errorf("failed to parse scan result: %s", err.Error())
+		}
+		p.Point = uint(i)
+		result.Points[i] = p
+	}
+
+	return result, nil
+}
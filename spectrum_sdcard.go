@@ -0,0 +1,107 @@
+package tinysa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SDFile represents a file on the SD card of the device.
+type SDFile struct {
+	Name string // File name
+	Size uint64 // File size in bytes
+}
+
+// ListSDFiles returns all files on the SD card. Only supported by the ultra model.
+func (d *Device) ListSDFiles() ([]SDFile, error) {
+	return d.ListSDFilesContext(context.Background())
+}
+
+// ListSDFilesContext is like ListSDFiles but uses ctx to cancel the command.
+func (d *Device) ListSDFilesContext(ctx context.Context) ([]SDFile, error) {
+	d.logger.Info("listing sd card files")
+
+	if err := d.checkSDCard(); err != nil {
+		return nil, err
+	}
+
+	res, err := d.sendCommand(ctx, "sd_list")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := parseSDListResponse(res)
+	if err != nil {
+		d.logger.Error("failed to parse sd_list response", "response", res, "err", err)
+		return nil, fmt.Errorf("failed to parse sd_list response: %s", err.Error())
+	}
+
+	return files, nil
+}
+
+// ReadSDFile reads the file with the given name from the SD card and returns its content. The whole file is held in
+// memory and must be transferred within the response timeout, which limits the size of a file that can be read (see
+// WithResponseTimeout). Only supported by the ultra model.
+func (d *Device) ReadSDFile(name string) ([]byte, error) {
+	return d.ReadSDFileContext(context.Background(), name)
+}
+
+// ReadSDFileContext is like ReadSDFile but uses ctx to cancel the command.
+func (d *Device) ReadSDFileContext(ctx context.Context, name string) ([]byte, error) {
+	d.logger.Info("reading sd card file", "name", name)
+
+	if err := d.checkSDCard(); err != nil {
+		return nil, err
+	}
+	if err := checkSDFileName(name); err != nil {
+		return nil, err
+	}
+
+	return d.sendCommandBinary(ctx, fmt.Sprintf("%s %s", sdReadCommand, name))
+}
+
+// DeleteSDFile deletes the file with the given name from the SD card. Only supported by the ultra model.
+func (d *Device) DeleteSDFile(name string) error {
+	return d.DeleteSDFileContext(context.Background(), name)
+}
+
+// DeleteSDFileContext is like DeleteSDFile but uses ctx to cancel the command.
+func (d *Device) DeleteSDFileContext(ctx context.Context, name string) error {
+	d.logger.Info("deleting sd card file", "name", name)
+
+	if err := d.checkSDCard(); err != nil {
+		return err
+	}
+	if err := checkSDFileName(name); err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("sd_delete %s", name)
+	res, err := d.sendCommand(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := parseSDDeleteResponse(cmd, res); err != nil {
+		d.logger.Error("failed to delete sd card file", "name", name, "response", res)
+		return err
+	}
+
+	return nil
+}
+
+// checkSDCard returns an error if the device model has no SD card slot.
+func (d *Device) checkSDCard() error {
+	if !d.sdCard {
+		return fmt.Errorf("sd card not supported by model %s: %w", d.model, ErrOptionNotSupportedByModel)
+	}
+	return nil
+}
+
+// checkSDFileName returns an error if name can't be passed as single shell argument.
+func checkSDFileName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("invalid sd card file name %q", name)
+	}
+	return nil
+}
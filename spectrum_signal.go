@@ -1,36 +1,110 @@
 package tinysa
 
+import (
+	"context"
+	"fmt"
+)
+
+// InputMode selects the input path of the spectrum analyzer.
+type InputMode int
+
+const (
+	// InputLow uses the low input, the mode after power on.
+	InputLow InputMode = iota
+
+	// InputHigh uses the high input for higher frequencies.
+	InputHigh
+)
+
+// String returns the firmware name of the mode.
+func (m InputMode) String() string {
+	switch m {
+	case InputLow:
+		return "low"
+	case InputHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("InputMode(%d)", int(m))
+	}
+}
+
+// SetInputMode switches the analyzer to the given input. The firmware cannot report the input, so the device
+// remembers the last mode set here, see InputMode.
+func (d *Device) SetInputMode(mode InputMode) error {
+	return d.SetInputModeContext(context.Background(), mode)
+}
+
+// SetInputModeContext is like SetInputMode but uses ctx to cancel the command.
+func (d *Device) SetInputModeContext(ctx context.Context, mode InputMode) error {
+	d.logger.Info("setting input mode", "mode", mode)
+	if err := d.sendSetCommand(ctx, fmt.Sprintf("mode %s input", mode)); err != nil {
+		return err
+	}
+	d.infoMutex.Lock()
+	defer d.infoMutex.Unlock()
+	d.inputMode = mode
+	return nil
+}
+
+// InputMode returns the input last set with SetInputMode, or InputLow if it was never set. An input selected on the
+// device itself is not known.
+func (d *Device) InputMode() InputMode {
+	d.infoMutex.Lock()
+	defer d.infoMutex.Unlock()
+	return d.inputMode
+}
+
 // EnableSpurRemoval enables spur removal.
 func (d *Device) EnableSpurRemoval() error {
+	return d.EnableSpurRemovalContext(context.Background())
+}
+
+// EnableSpurRemovalContext is like EnableSpurRemoval but uses ctx to cancel the command.
+func (d *Device) EnableSpurRemovalContext(ctx context.Context) error {
 	d.logger.Info("enabling spur removal")
-	_, err := d.sendCommand("spur on")
-	return err
+	return d.sendSetCommand(ctx, "spur on")
 }
 
 // DisableSpurRemoval disables spur removal.
 func (d *Device) DisableSpurRemoval() error {
+	return d.DisableSpurRemovalContext(context.Background())
+}
+
+// DisableSpurRemovalContext is like DisableSpurRemoval but uses ctx to cancel the command.
+func (d *Device) DisableSpurRemovalContext(ctx context.Context) error {
 	d.logger.Info("disabling spur removal")
-	_, err := d.sendCommand("spur off")
-	return err
+	return d.sendSetCommand(ctx, "spur off")
 }
 
 // EnableAutoSpurRemoval sets spur removal to auto.
 func (d *Device) EnableAutoSpurRemoval() error {
+	return d.EnableAutoSpurRemovalContext(context.Background())
+}
+
+// EnableAutoSpurRemovalContext is like EnableAutoSpurRemoval but uses ctx to cancel the command.
+func (d *Device) EnableAutoSpurRemovalContext(ctx context.Context) error {
 	d.logger.Info("enabling auto spur removal")
-	_, err := d.sendCommand("spur auto")
-	return err
+	return d.sendSetCommand(ctx, "spur auto")
 }
 
 // EnableLNA enables the low noise amplifier.
 func (d *Device) EnableLNA() error {
+	return d.EnableLNAContext(context.Background())
+}
+
+// EnableLNAContext is like EnableLNA but uses ctx to cancel the command.
+func (d *Device) EnableLNAContext(ctx context.Context) error {
 	d.logger.Info("enabling lna")
-	_, err := d.sendCommand("lna on")
-	return err
+	return d.sendSetCommand(ctx, "lna on")
 }
 
 // DisableLNA disables the low noise amplifier.
 func (d *Device) DisableLNA() error {
+	return d.DisableLNAContext(context.Background())
+}
+
+// DisableLNAContext is like DisableLNA but uses ctx to cancel the command.
+func (d *Device) DisableLNAContext(ctx context.Context) error {
 	d.logger.Info("disabling lna")
-	_, err := d.sendCommand("lna off")
-	return err
+	return d.sendSetCommand(ctx, "lna off")
 }
@@ -0,0 +1,177 @@
+package tinysa
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// streamBackoffMin is the wait after a failed acquisition, doubled for every further failure in a row.
+	streamBackoffMin = 50 * time.Millisecond
+
+	// streamBackoffMax is the maximum wait between failed acquisitions.
+	streamBackoffMax = 5 * time.Second
+)
+
+// StreamPolicy defines what Stream does when the consumer does not keep up with the acquired frames.
+type StreamPolicy int
+
+const (
+	// StreamBlock waits until the consumer has received the frame before acquiring the next one (backpressure).
+	StreamBlock StreamPolicy = iota
+
+	// StreamDropOldest never waits for the consumer, but drops the oldest buffered frame to make room for a new one.
+	StreamDropOldest
+)
+
+// StreamOptions configures a sweep stream.
+type StreamOptions struct {
+	Trace    uint          // Trace id to acquire, defaults to 1
+	Interval time.Duration // Minimum time between the start of two acquisitions, 0 acquires back to back
+	Buffer   int           // Number of frames buffered in the channel, at least 1 for StreamDropOldest
+	Policy   StreamPolicy  // Behaviour when the buffer is full
+}
+
+// SweepFrame is a single acquisition of a sweep stream.
+type SweepFrame struct {
+	Time  time.Time   // Time the acquisition finished
+	Sweep Sweep       // Sweep settings the data was taken with
+	Data  []TraceData // Trace data of the sweep
+	Err   error       // Error of the acquisition, if any; Sweep and Data are empty then
+}
+
+// Stream repeatedly acquires the sweep settings and trace data of the configured trace and sends them as SweepFrame
+// to the returned channel. Each acquisition holds the device lock for all of its commands, so other commands can be
+// used concurrently but never change the sweep in the middle of a frame. Failed acquisitions are delivered as frame
+// with Err set, and the stream continues after a backoff growing with the number of failures in a row. The channel is
+// closed after ctx is done.
+func (d *Device) Stream(ctx context.Context, opts StreamOptions) (<-chan SweepFrame, error) {
+	d.logger.Info("starting stream", "options", opts)
+
+	if opts.Trace == 0 {
+		opts.Trace = 1
+	}
+	if opts.Interval < 0 {
+		return nil, fmt.Errorf("invalid stream interval %s", opts.Interval)
+	}
+	if opts.Buffer < 0 {
+		return nil, fmt.Errorf("invalid stream buffer size %d", opts.Buffer)
+	}
+	switch opts.Policy {
+	case StreamBlock:
+	case StreamDropOldest:
+		opts.Buffer = max(opts.Buffer, 1)
+	default:
+		return nil, fmt.Errorf("invalid stream policy %d", opts.Policy)
+	}
+
+	frames := make(chan SweepFrame, opts.Buffer)
+	go d.stream(ctx, opts, frames)
+
+	return frames, nil
+}
+
+// stream is the acquisition loop of Stream.
+func (d *Device) stream(ctx context.Context, opts StreamOptions, frames chan SweepFrame) {
+	defer close(frames)
+
+	dropped := 0
+	var backoff time.Duration
+	for {
+		started := time.Now()
+
+		frame := d.acquireFrame(ctx, opts.Trace)
+		if ctx.Err() != nil {
+			d.logger.Info("stopping stream", "dropped", dropped)
+			return
+		}
+		if frame.Err != nil {
+			backoff = min(max(2*backoff, streamBackoffMin), streamBackoffMax)
+			d.logger.Warn("failed to acquire stream frame", "err", frame.Err, "backoff", backoff)
+		} else {
+			backoff = 0
+		}
+
+		switch opts.Policy {
+		case StreamBlock:
+			select {
+			case frames <- frame:
+			case <-ctx.Done():
+				d.logger.Info("stopping stream", "dropped", dropped)
+				return
+			}
+		case StreamDropOldest:
+			for sent := false; !sent; {
+				select {
+				case frames <- frame:
+					sent = true
+				default:
+					select {
+					case <-frames:
+						dropped++
+						d.logger.Debug("dropped oldest stream frame", "dropped", dropped)
+					default:
+					}
+				}
+			}
+		}
+
+		if wait := max(opts.Interval-time.Since(started), backoff); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				d.logger.Info("stopping stream", "dropped", dropped)
+				return
+			}
+		}
+	}
+}
+
+// acquireFrame reads sweep settings, trace values and frequencies while holding the device lock, so no other command
+// can change the sweep in between.
+func (d *Device) acquireFrame(ctx context.Context, traceID uint) SweepFrame {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	send := func(cmd string) (string, error) {
+		res, err := d.send(ctx, cmd)
+		return string(res), err
+	}
+
+	res, err := send("sweep")
+	if err != nil {
+		return SweepFrame{Time: time.Now(), Err: err}
+	}
+	sweep, err := parseSweepResponse(res)
+	if err != nil {
+		return SweepFrame{Time: time.Now(), Err: fmt.Errorf("failed to parse sweep response: %s", err.Error())}
+	}
+
+	res, err = send(fmt.Sprintf("trace %d value", traceID))
+	if err != nil {
+		return SweepFrame{Time: time.Now(), Err: err}
+	}
+	values, err := parseTraceValuesResponse(res)
+	if err != nil {
+		return SweepFrame{Time: time.Now(), Err: err}
+	}
+
+	res, err = send("frequencies")
+	if err != nil {
+		return SweepFrame{Time: time.Now(), Err: err}
+	}
+	frequencies, err := parseFrequenciesResponse(res)
+	if err != nil {
+		return SweepFrame{Time: time.Now(), Err: err}
+	}
+
+	data, err := combineTraceData(values, frequencies)
+	if err != nil {
+		return SweepFrame{Time: time.Now(), Err: err}
+	}
+
+	return SweepFrame{Time: time.Now(), Sweep: sweep, Data: data}
+}
@@ -0,0 +1,161 @@
+package tinysa
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+func TestStream(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	if err := dev.SetSweepStartStopWithPoints(100e6, 200e6, 51); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	frames, err := dev.Stream(ctx, StreamOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	var last time.Time
+	for range 3 {
+		frame := <-frames
+		if frame.Err != nil {
+			t.Fatalf("unexpected frame error: %s", frame.Err.Error())
+		}
+		if want := (Sweep{100e6, 200e6, 51}); frame.Sweep != want {
+			t.Errorf("frame sweep = %+v, want %+v", frame.Sweep, want)
+		}
+		if len(frame.Data) != 51 || frame.Data[50].Frequency != 200e6 || frame.Data[0].Trace != 1 {
+			t.Errorf("unexpected frame data, %d points", len(frame.Data))
+		}
+		if frame.Time.Before(last) {
+			t.Errorf("frame time %s before previous frame %s", frame.Time, last)
+		}
+		last = frame.Time
+	}
+
+	cancel()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-frames:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("stream channel not closed after cancel")
+		}
+	}
+}
+
+func TestStreamConcurrentCommands(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	frames, err := dev.Stream(ctx, StreamOptions{Policy: StreamDropOldest, Buffer: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Change the sweep while streaming; every frame must be consistent with its own sweep settings.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 10 {
+			if err := dev.SetSweepStartStopWithPoints(100e6, 200e6, uint(11+i*10)); err != nil {
+				t.Errorf("unexpected error: %s", err.Error())
+			}
+		}
+	}()
+
+	for range 10 {
+		frame := <-frames
+		if frame.Err != nil {
+			t.Fatalf("unexpected frame error: %s", frame.Err.Error())
+		}
+		if uint(len(frame.Data)) != frame.Sweep.Points {
+			t.Errorf("frame has %d points, but sweep %+v", len(frame.Data), frame.Sweep)
+		}
+	}
+
+	wg.Wait()
+}
+
+func TestStreamDropOldest(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	frames, err := dev.Stream(ctx, StreamOptions{Policy: StreamDropOldest, Buffer: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Let the stream overrun the buffer, then expect the buffered frames to be recent ones.
+	time.Sleep(100 * time.Millisecond)
+	before := time.Now()
+	time.Sleep(50 * time.Millisecond)
+
+	frame := <-frames
+	if frame.Time.Before(before) {
+		t.Errorf("expected a recent frame, got frame from %s", before.Sub(frame.Time))
+	}
+}
+
+func TestStreamErrorBackoff(t *testing.T) {
+	dev, sim := newTestDevice(t, tinysatest.ModelUltra)
+	_ = sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	frames, err := dev.Stream(ctx, StreamOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Failed acquisitions back off 50, 100 and 200 ms, so only a few error frames fit into the timeout.
+	count := 0
+	for frame := range frames {
+		if frame.Err == nil {
+			t.Fatal("expected frame error after the connection was closed")
+		}
+		count++
+	}
+	if count == 0 || count > 5 {
+		t.Errorf("received %d error frames, want 1..5", count)
+	}
+}
+
+func TestStreamInvalidOptions(t *testing.T) {
+	dev, _ := newTestDevice(t, tinysatest.ModelUltra)
+
+	tests := []struct {
+		name string
+		opts StreamOptions
+	}{
+		{name: "negative interval", opts: StreamOptions{Interval: -time.Second}},
+		{name: "negative buffer", opts: StreamOptions{Buffer: -1}},
+		{name: "invalid policy", opts: StreamOptions{Policy: 42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := dev.Stream(context.Background(), tt.opts); err == nil {
+				t.Errorf("expected error for %+v", tt.opts)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package tinysa
 
 import (
+	"context"
 	"fmt"
+	"time"
 )
 
 // Sweep defines a frequency sweep with start/stop frequencies and number of points.
@@ -84,9 +86,14 @@ const (
 
 // GetSweep returns the current start and stop frequencies and sweep points as a Sweep struct.
 func (d *Device) GetSweep() (Sweep, error) {
+	return d.GetSweepContext(context.Background())
+}
+
+// GetSweepContext is like GetSweep but uses ctx to cancel the command.
+func (d *Device) GetSweepContext(ctx context.Context) (Sweep, error) {
 	d.logger.Info("requesting sweep")
 
-	line, err := d.sendCommand("sweep")
+	line, err := d.sendCommand(ctx, "sweep")
 	if err != nil {
 		return Sweep{}, err
 	}
@@ -102,9 +109,14 @@ func (d *Device) GetSweep() (Sweep, error) {
 
 // GetSweepStatus returns the current sweep status as SweepStatus type.
 func (d *Device) GetSweepStatus() (SweepStatus, error) {
+	return d.GetSweepStatusContext(context.Background())
+}
+
+// GetSweepStatusContext is like GetSweepStatus but uses ctx to cancel the command.
+func (d *Device) GetSweepStatusContext(ctx context.Context) (SweepStatus, error) {
 	d.logger.Debug("requesting sweep status")
 
-	res, err := d.sendCommand("status")
+	res, err := d.sendCommand(ctx, "status")
 	if err != nil {
 		return SweepStatusUnknown, err
 	}
@@ -122,89 +134,164 @@ func (d *Device) GetSweepStatus() (SweepStatus, error) {
 
 // SetSweepMode sets the sweep mode.
 func (d *Device) SetSweepMode(mode SweepMode) error {
+	return d.SetSweepModeContext(context.Background(), mode)
+}
+
+// SetSweepModeContext is like SetSweepMode but uses ctx to cancel the command.
+func (d *Device) SetSweepModeContext(ctx context.Context, mode SweepMode) error {
 	d.logger.Info("setting sweep mode", "mode", mode)
-	_, err := d.sendCommand(fmt.Sprintf("sweep %s", mode))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("sweep %s", mode))
 }
 
 // SetSweepStart sets the sweep start frequency in Hz.
 func (d *Device) SetSweepStart(freqHz uint64) error {
+	return d.SetSweepStartContext(context.Background(), freqHz)
+}
+
+// SetSweepStartContext is like SetSweepStart but uses ctx to cancel the command.
+func (d *Device) SetSweepStartContext(ctx context.Context, freqHz uint64) error {
 	d.logger.Info("setting sweep start", "freq", freqHz)
-	_, err := d.sendCommand(fmt.Sprintf("sweep start %d", freqHz))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("sweep start %d", freqHz))
 }
 
 // SetSweepStop sets the sweep stop frequency in Hz.
 func (d *Device) SetSweepStop(freqHz uint64) error {
+	return d.SetSweepStopContext(context.Background(), freqHz)
+}
+
+// SetSweepStopContext is like SetSweepStop but uses ctx to cancel the command.
+func (d *Device) SetSweepStopContext(ctx context.Context, freqHz uint64) error {
 	d.logger.Info("setting sweep stop", "freq", freqHz)
-	_, err := d.sendCommand(fmt.Sprintf("sweep stop %d", freqHz))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("sweep stop %d", freqHz))
 }
 
 // SetSweepCenter sets the sweep center frequency in Hz.
 func (d *Device) SetSweepCenter(freqHz uint64) error {
+	return d.SetSweepCenterContext(context.Background(), freqHz)
+}
+
+// SetSweepCenterContext is like SetSweepCenter but uses ctx to cancel the command.
+func (d *Device) SetSweepCenterContext(ctx context.Context, freqHz uint64) error {
 	d.logger.Info("setting sweep center", "freq", freqHz)
-	_, err := d.sendCommand(fmt.Sprintf("sweep center %d", freqHz))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("sweep center %d", freqHz))
 }
 
 // SetSweepSpan sets the sweep span frequency in Hz.
 func (d *Device) SetSweepSpan(freqHz uint64) error {
+	return d.SetSweepSpanContext(context.Background(), freqHz)
+}
+
+// SetSweepSpanContext is like SetSweepSpan but uses ctx to cancel the command.
+func (d *Device) SetSweepSpanContext(ctx context.Context, freqHz uint64) error {
 	d.logger.Info("setting sweep span", "freq", freqHz)
-	_, err := d.sendCommand(fmt.Sprintf("sweep span %d", freqHz))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("sweep span %d", freqHz))
 }
 
 // SetSweepContinuousWave sets the sweep to continuous wave mode at the specified frequency in Hz.
 func (d *Device) SetSweepContinuousWave(freqHz uint64) error {
+	return d.SetSweepContinuousWaveContext(context.Background(), freqHz)
+}
+
+// SetSweepContinuousWaveContext is like SetSweepContinuousWave but uses ctx to cancel the command.
+func (d *Device) SetSweepContinuousWaveContext(ctx context.Context, freqHz uint64) error {
 	d.logger.Info("setting sweep continuous wave", "freq", freqHz)
-	_, err := d.sendCommand(fmt.Sprintf("sweep cw %d", freqHz))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("sweep cw %d", freqHz))
 }
 
 // SetSweepStartStop sets the sweep start and stop frequency in Hz.
 func (d *Device) SetSweepStartStop(freqStartHz uint64, freqStopHz uint64) error {
+	return d.SetSweepStartStopContext(context.Background(), freqStartHz, freqStopHz)
+}
+
+// SetSweepStartStopContext is like SetSweepStartStop but uses ctx to cancel the command.
+func (d *Device) SetSweepStartStopContext(ctx context.Context, freqStartHz uint64, freqStopHz uint64) error {
 	d.logger.Info("set sweep start and stop", "freq_start", freqStartHz, "freq_stop", freqStopHz)
-	_, err := d.sendCommand(fmt.Sprintf("sweep %d %d", freqStartHz, freqStopHz))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("sweep %d %d", freqStartHz, freqStopHz))
 }
 
 // SetSweepStartStopWithPoints sets the sweep start and stop frequencies in Hz and the number of sweep points.
 func (d *Device) SetSweepStartStopWithPoints(freqStartHz uint64, freqStopHz uint64, points uint) error {
+	return d.SetSweepStartStopWithPointsContext(context.Background(), freqStartHz, freqStopHz, points)
+}
+
+// SetSweepStartStopWithPointsContext is like SetSweepStartStopWithPoints but uses ctx to cancel the command.
+func (d *Device) SetSweepStartStopWithPointsContext(ctx context.Context, freqStartHz uint64, freqStopHz uint64, points uint) error {
 	d.logger.Info("set sweep start, stop and points", "freq_start", freqStartHz, "freq_stop", freqStopHz, "points", points)
-	_, err := d.sendCommand(fmt.Sprintf("sweep %d %d %d", freqStartHz, freqStopHz, points))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("sweep %d %d %d", freqStartHz, freqStopHz, points))
 }
 
-// SetSweepTime sets the sweep time in microseconds.
-// TODO: use time type
-func (d *Device) SetSweepTime(timeUs uint64) error {
-	d.logger.Info("setting sweep time", "time", timeUs)
-	_, err := d.sendCommand(fmt.Sprintf("sweeptime %du", timeUs))
-	return err
+// GetSweepTime returns the current sweep time.
+func (d *Device) GetSweepTime() (time.Duration, error) {
+	return d.GetSweepTimeContext(context.Background())
+}
+
+// GetSweepTimeContext is like GetSweepTime but uses ctx to cancel the command.
+func (d *Device) GetSweepTimeContext(ctx context.Context) (time.Duration, error) {
+	d.logger.Info("requesting sweep time")
+
+	res, err := d.sendCommand(ctx, "sweeptime")
+	if err != nil {
+		return 0, err
+	}
+
+	sweepTime, err := parseSweepTimeResponse(res)
+	if err != nil {
+		d.logger.Error("failed to parse sweep time response", "response", res, "err", err)
+		return 0, fmt.Errorf("failed to parse sweep time response: %s", err.Error())
+	}
+
+	return sweepTime, nil
+}
+
+// SetSweepTime sets the sweep time, with microsecond resolution. A sweep time of 0 lets the device select the
+// fastest possible sweep time.
+func (d *Device) SetSweepTime(sweepTime time.Duration) error {
+	return d.SetSweepTimeContext(context.Background(), sweepTime)
+}
+
+// SetSweepTimeContext is like SetSweepTime but uses ctx to cancel the command.
+func (d *Device) SetSweepTimeContext(ctx context.Context, sweepTime time.Duration) error {
+	d.logger.Info("setting sweep time", "time", sweepTime)
+	if sweepTime < 0 {
+		return fmt.Errorf("invalid sweep time %s", sweepTime)
+	}
+	return d.sendSetCommand(ctx, fmt.Sprintf("sweeptime %du", sweepTime.Microseconds()))
 }
 
 // SetSweepPoints updates the number of sweep points while preserving the current start and stop frequencies.
 func (d *Device) SetSweepPoints(points uint) error {
+	return d.SetSweepPointsContext(context.Background(), points)
+}
+
+// SetSweepPointsContext is like SetSweepPoints but uses ctx to cancel the command.
+func (d *Device) SetSweepPointsContext(ctx context.Context, points uint) error {
 	// Sweep points can't be set directly; retrieve current sweep settings
 	// and apply them together with the new point count.
-	sweep, err := d.GetSweep()
+	sweep, err := d.GetSweepContext(ctx)
 	if err != nil {
 		return err
 	}
-	return d.SetSweepStartStopWithPoints(sweep.Start, sweep.Stop, points)
+	return d.SetSweepStartStopWithPointsContext(ctx, sweep.Start, sweep.Stop, points)
 }
 
 // PauseSweep pauses the ongoing sweep operation.
 func (d *Device) PauseSweep() error {
+	return d.PauseSweepContext(context.Background())
+}
+
+// PauseSweepContext is like PauseSweep but uses ctx to cancel the command.
+func (d *Device) PauseSweepContext(ctx context.Context) error {
 	d.logger.Info("pausing sweep")
-	_, err := d.sendCommand("pause")
-	return err
+	return d.sendSetCommand(ctx, "pause")
 }
 
 // ResumeSweep resumes the paused sweep operation.
 func (d *Device) ResumeSweep() error {
+	return d.ResumeSweepContext(context.Background())
+}
+
+// ResumeSweepContext is like ResumeSweep but uses ctx to cancel the command.
+func (d *Device) ResumeSweepContext(ctx context.Context) error {
 	d.logger.Info("resuming sweep")
-	_, err := d.sendCommand("resume")
-	return err
+	return d.sendSetCommand(ctx, "resume")
 }
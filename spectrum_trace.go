@@ -1,8 +1,8 @@
 package tinysa
 
 import (
+	"context"
 	"fmt"
-	"strconv"
 	"strings"
 )
 
@@ -181,9 +181,14 @@ func TraceCalcOptions() []string {
 
 // GetTrace returns trace information for the specified trace id as Trace struct.
 func (d *Device) GetTrace(traceID uint) (Trace, error) {
+	return d.GetTraceContext(context.Background(), traceID)
+}
+
+// GetTraceContext is like GetTrace but uses ctx to cancel the command.
+func (d *Device) GetTraceContext(ctx context.Context, traceID uint) (Trace, error) {
 	d.logger.Info("requesting trace information", "trace_id", traceID)
 
-	line, err := d.sendCommand(fmt.Sprintf("trace %d", traceID))
+	line, err := d.sendCommand(ctx, fmt.Sprintf("trace %d", traceID))
 	if err != nil {
 		return Trace{}, err
 	}
@@ -199,9 +204,14 @@ func (d *Device) GetTrace(traceID uint) (Trace, error) {
 
 // GetTraceAll returns trace information for all traces as Trace slice.
 func (d *Device) GetTraceAll() ([]Trace, error) {
+	return d.GetTraceAllContext(context.Background())
+}
+
+// GetTraceAllContext is like GetTraceAll but uses ctx to cancel the command.
+func (d *Device) GetTraceAllContext(ctx context.Context) ([]Trace, error) {
 	d.logger.Info("requesting trace information")
 
-	statusStr, err := d.sendCommand("trace")
+	statusStr, err := d.sendCommand(ctx, "trace")
 	if err != nil {
 		return nil, err
 	}
@@ -223,23 +233,22 @@ func (d *Device) GetTraceAll() ([]Trace, error) {
 
 // GetTraceFrequencies returns a list of frequencies as uint64 list for the current (or really all) traces.
 func (d *Device) GetTraceFrequencies() ([]uint64, error) {
+	return d.GetTraceFrequenciesContext(context.Background())
+}
+
+// GetTraceFrequenciesContext is like GetTraceFrequencies but uses ctx to cancel the command.
+func (d *Device) GetTraceFrequenciesContext(ctx context.Context) ([]uint64, error) {
 	d.logger.Info("getting trace frequencies")
 
-	freqStr, err := d.sendCommand("frequencies")
+	freqStr, err := d.sendCommand(ctx, "frequencies")
 	if err != nil {
 		return nil, err
 	}
-	freqList := strings.Split(freqStr, commandTerminator)
 
-	result := make([]uint64, len(freqList))
-	for i, freq := range freqList {
-		freqInt, err := strconv.ParseUint(freq, 10, 64)
-		if err != nil {
-			d.logger.Error("failed to parse trace frequency", "freq", freq, "err", err)
-			return nil, fmt.Errorf("failed to parse frequency %q as int: %s", freq, err.Error())
-		}
-
-		result[i] = freqInt
+	result, err := parseFrequenciesResponse(freqStr)
+	if err != nil {
+		d.logger.Error("failed to parse trace frequencies", "err", err)
+		return nil, err
 	}
 
 	return result, nil
@@ -247,21 +256,22 @@ func (d *Device) GetTraceFrequencies() ([]uint64, error) {
 
 // GetTraceValues returns the list of the trace values as a TraceValue slice.
 func (d *Device) GetTraceValues(traceID uint) ([]TraceValue, error) {
+	return d.GetTraceValuesContext(context.Background(), traceID)
+}
+
+// GetTraceValuesContext is like GetTraceValues but uses ctx to cancel the command.
+func (d *Device) GetTraceValuesContext(ctx context.Context, traceID uint) ([]TraceValue, error) {
 	d.logger.Info("getting trace values", "trace_id", traceID)
 
-	dataStr, err := d.sendCommand(fmt.Sprintf("trace %d value", traceID))
+	dataStr, err := d.sendCommand(ctx, fmt.Sprintf("trace %d value", traceID))
 	if err != nil {
 		return nil, err
 	}
-	dataList := strings.Split(dataStr, commandTerminator)
 
-	data := make([]TraceValue, len(dataList))
-	for i, line := range dataList {
-		data[i], err = parseTraceValueResponseLine(line)
-		if err != nil {
-			d.logger.Error("failed to parse trace value", "trace_id", traceID, "line", line)
-			return nil, fmt.Errorf("failed to parse trace result: %s", err.Error())
-		}
+	data, err := parseTraceValuesResponse(dataStr)
+	if err != nil {
+		d.logger.Error("failed to parse trace values", "trace_id", traceID, "err", err)
+		return nil, err
 	}
 
 	return data, nil
@@ -269,22 +279,37 @@ func (d *Device) GetTraceValues(traceID uint) ([]TraceValue, error) {
 
 // GetTraceData returns a combined list of frequencies and values as a TraceData slice.
 func (d *Device) GetTraceData(traceID uint) ([]TraceData, error) {
+	return d.GetTraceDataContext(context.Background(), traceID)
+}
+
+// GetTraceDataContext is like GetTraceData but uses ctx to cancel the command.
+func (d *Device) GetTraceDataContext(ctx context.Context, traceID uint) ([]TraceData, error) {
 	d.logger.Info("getting trace data", "trace_id", traceID)
 
-	values, err := d.GetTraceValues(traceID)
+	values, err := d.GetTraceValuesContext(ctx, traceID)
+	if err != nil {
+		return nil, err
+	}
+
+	frequencies, err := d.GetTraceFrequenciesContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	frequencies, err := d.GetTraceFrequencies()
+	data, err := combineTraceData(values, frequencies)
 	if err != nil {
+		d.logger.Error("failed to combine trace data", "trace_id", traceID, "values", values, "frequencies", frequencies)
 		return nil, err
 	}
 
+	return data, nil
+}
+
+// combineTraceData combines trace values and frequencies of the same length into a TraceData slice.
+func combineTraceData(values []TraceValue, frequencies []uint64) ([]TraceData, error) {
 	lenValues := len(values)
 	lenFreq := len(frequencies)
 	if lenValues != lenFreq {
-		d.logger.Error("value and frequency values lengths do not match", "trace_id", traceID, "values", values, "frequencies", frequencies)
 		return nil, fmt.Errorf("value and frequency values lengths do not match, %d != %d", lenValues, lenFreq)
 	}
 
@@ -303,62 +328,88 @@ func (d *Device) GetTraceData(traceID uint) ([]TraceData, error) {
 
 // EnableTrace enables the display of the specified trace.
 func (d *Device) EnableTrace(traceID uint) error {
+	return d.EnableTraceContext(context.Background(), traceID)
+}
+
+// EnableTraceContext is like EnableTrace but uses ctx to cancel the command.
+func (d *Device) EnableTraceContext(ctx context.Context, traceID uint) error {
 	d.logger.Info("enabling trace", "trace_id", traceID)
-	_, err := d.sendCommand(fmt.Sprintf("trace %d view on", traceID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("trace %d view on", traceID))
 }
 
 // DisableTrace disables the display of the specified trace.
 func (d *Device) DisableTrace(traceID uint) error {
-	_, err := d.sendCommand(fmt.Sprintf("trace %d view off", traceID))
-	return err
+	return d.DisableTraceContext(context.Background(), traceID)
+}
+
+// DisableTraceContext is like DisableTrace but uses ctx to cancel the command.
+func (d *Device) DisableTraceContext(ctx context.Context, traceID uint) error {
+	return d.sendSetCommand(ctx, fmt.Sprintf("trace %d view off", traceID))
 }
 
 // EnableTraceCalc enables trace calculations like TraceCalcMaxH or TraceCalcQuasi for the specified trace.
 func (d *Device) EnableTraceCalc(traceID uint, calc TraceCalc) error {
+	return d.EnableTraceCalcContext(context.Background(), traceID, calc)
+}
+
+// EnableTraceCalcContext is like EnableTraceCalc but uses ctx to cancel the command.
+func (d *Device) EnableTraceCalcContext(ctx context.Context, traceID uint, calc TraceCalc) error {
 	d.logger.Info("enabling trace calculations", "trace_id", traceID, "calc", calc)
 
-	// calc log and lin is only supported on ultra
-	/*if d.model != ModelUltra {
-		if calc == TraceCalcLin || calc == TraceCalcLog {
-			return ErrOptionNotSupportedByModel
-		}
-	}*/
-	_, err := d.sendCommand(fmt.Sprintf("calc %d %s", traceID, calc.String()))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("calc %d %s", traceID, calc.String()))
 }
 
 // DisableTraceCalc disables calculation for the specified trace.
 func (d *Device) DisableTraceCalc(traceID uint) error {
+	return d.DisableTraceCalcContext(context.Background(), traceID)
+}
+
+// DisableTraceCalcContext is like DisableTraceCalc but uses ctx to cancel the command.
+func (d *Device) DisableTraceCalcContext(ctx context.Context, traceID uint) error {
 	d.logger.Info("disabling trace calculations", "trace_id", traceID)
-	_, err := d.sendCommand(fmt.Sprintf("calc %d off", traceID))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("calc %d off", traceID))
 }
 
 // SetTraceUnit sets the display unit to the specified value.
 func (d *Device) SetTraceUnit(unit TraceUnit) error {
+	return d.SetTraceUnitContext(context.Background(), unit)
+}
+
+// SetTraceUnitContext is like SetTraceUnit but uses ctx to cancel the command.
+func (d *Device) SetTraceUnitContext(ctx context.Context, unit TraceUnit) error {
 	d.logger.Info("setting display unit", "unit", unit)
-	_, err := d.sendCommand(fmt.Sprintf("trace %s", unit.value))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("trace %s", unit.value))
 }
 
 // SetTraceRefLevel sets the display ref level to the specified value in dBm.
 func (d *Device) SetTraceRefLevel(levelDbm int) error {
+	return d.SetTraceRefLevelContext(context.Background(), levelDbm)
+}
+
+// SetTraceRefLevelContext is like SetTraceRefLevel but uses ctx to cancel the command.
+func (d *Device) SetTraceRefLevelContext(ctx context.Context, levelDbm int) error {
 	d.logger.Info("setting trace ref level", "level", levelDbm)
-	_, err := d.sendCommand(fmt.Sprintf("trace reflevel %d", levelDbm))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("trace reflevel %d", levelDbm))
 }
 
 // SetTraceRefLevelAuto sets the display ref level to auto.
 func (d *Device) SetTraceRefLevelAuto() error {
+	return d.SetTraceRefLevelAutoContext(context.Background())
+}
+
+// SetTraceRefLevelAutoContext is like SetTraceRefLevelAuto but uses ctx to cancel the command.
+func (d *Device) SetTraceRefLevelAutoContext(ctx context.Context) error {
 	d.logger.Info("setting trace ref level auto")
-	_, err := d.sendCommand("trace reflevel auto")
-	return err
+	return d.sendSetCommand(ctx, "trace reflevel auto")
 }
 
 // SetTraceScale sets the display scale to the specified value.
 func (d *Device) SetTraceScale(level float64) error {
+	return d.SetTraceScaleContext(context.Background(), level)
+}
+
+// SetTraceScaleContext is like SetTraceScale but uses ctx to cancel the command.
+func (d *Device) SetTraceScaleContext(ctx context.Context, level float64) error {
 	d.logger.Info("setting trace scale", "level", level)
-	_, err := d.sendCommand(fmt.Sprintf("trace scale %.3f", level))
-	return err
+	return d.sendSetCommand(ctx, fmt.Sprintf("trace scale %.3f", level))
 }
@@ -0,0 +1,120 @@
+package tinysa
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TriggerMode defines when the device starts a sweep.
+type TriggerMode string
+
+const (
+	// TriggerAuto sweeps continuously without waiting for a trigger (free-running).
+	TriggerAuto TriggerMode = "auto"
+
+	// TriggerNormal sweeps every time the signal crosses the trigger level.
+	TriggerNormal TriggerMode = "normal"
+
+	// TriggerSingle waits for the signal to cross the trigger level once, then sweeps once and pauses.
+	TriggerSingle TriggerMode = "single"
+)
+
+// TriggerEdge defines the direction in which the signal has to cross the trigger level.
+type TriggerEdge string
+
+const (
+	// TriggerEdgeUp triggers when the signal rises above the trigger level.
+	TriggerEdgeUp TriggerEdge = "up"
+
+	// TriggerEdgeDown triggers when the signal falls below the trigger level.
+	TriggerEdgeDown TriggerEdge = "down"
+)
+
+// triggerPollInterval is the interval in which WaitForTrigger polls the sweep status.
+const triggerPollInterval = 50 * time.Millisecond
+
+// SetTriggerMode sets the trigger mode.
+func (d *Device) SetTriggerMode(mode TriggerMode) error {
+	return d.SetTriggerModeContext(context.Background(), mode)
+}
+
+// SetTriggerModeContext is like SetTriggerMode but uses ctx to cancel the command.
+func (d *Device) SetTriggerModeContext(ctx context.Context, mode TriggerMode) error {
+	d.logger.Info("setting trigger mode", "mode", mode)
+
+	switch mode {
+	case TriggerAuto, TriggerNormal, TriggerSingle:
+	default:
+		return fmt.Errorf("invalid trigger mode %q", mode)
+	}
+
+	return d.sendSetCommand(ctx, fmt.Sprintf("trigger %s", mode))
+}
+
+// SetTriggerLevel sets the trigger level in dBm.
+func (d *Device) SetTriggerLevel(levelDbm float64) error {
+	return d.SetTriggerLevelContext(context.Background(), levelDbm)
+}
+
+// SetTriggerLevelContext is like SetTriggerLevel but uses ctx to cancel the command.
+func (d *Device) SetTriggerLevelContext(ctx context.Context, levelDbm float64) error {
+	d.logger.Info("setting trigger level", "level", levelDbm)
+	return d.sendSetCommand(ctx, fmt.Sprintf("trigger %s", strconv.FormatFloat(levelDbm, 'f', -1, 64)))
+}
+
+// SetTriggerEdge sets the trigger edge.
+func (d *Device) SetTriggerEdge(edge TriggerEdge) error {
+	return d.SetTriggerEdgeContext(context.Background(), edge)
+}
+
+// SetTriggerEdgeContext is like SetTriggerEdge but uses ctx to cancel the command.
+func (d *Device) SetTriggerEdgeContext(ctx context.Context, edge TriggerEdge) error {
+	d.logger.Info("setting trigger edge", "edge", edge)
+
+	switch edge {
+	case TriggerEdgeUp, TriggerEdgeDown:
+	default:
+		return fmt.Errorf("invalid trigger edge %q", edge)
+	}
+
+	return d.sendSetCommand(ctx, fmt.Sprintf("trigger %s", edge))
+}
+
+// WaitForTrigger arms a single triggered sweep and blocks until the device has finished it, then returns the data
+// of trace 1. Trigger level and edge must be configured beforehand. The sweep is resumed before arming the trigger,
+// so a sweep paused beforehand is not mistaken for the finished one. The device stays paused after the sweep; use
+// SetTriggerMode(TriggerAuto) to return to free-running sweeps. Cancel ctx to stop waiting.
+func (d *Device) WaitForTrigger(ctx context.Context) ([]TraceData, error) {
+	if err := d.ResumeSweepContext(ctx); err != nil {
+		return nil, err
+	}
+	if err := d.SetTriggerModeContext(ctx, TriggerSingle); err != nil {
+		return nil, err
+	}
+
+	d.logger.Info("waiting for trigger")
+
+	ticker := time.NewTicker(triggerPollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := d.GetSweepStatusContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if status == SweepStatusPaused {
+			break
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	d.logger.Info("triggered sweep done")
+	return d.GetTraceDataContext(ctx, 1)
+}
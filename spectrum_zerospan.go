@@ -0,0 +1,87 @@
+package tinysa
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ZeroSpan configures a zero-span (time domain) measurement at a single frequency.
+type ZeroSpan struct {
+	Frequency uint64        // Center frequency in Hz
+	RBW       uint64        // Resolution bandwidth in Hz, 0 selects it automatically
+	SweepTime time.Duration // Duration of one sweep, 0 selects the fastest possible sweep time
+}
+
+// TimeSample is a single point of a zero-span trace, tagged with its time offset from the start of the sweep.
+type TimeSample struct {
+	Trace  uint
+	Point  uint
+	Offset time.Duration
+	Value  float64
+}
+
+// SetZeroSpan puts the device in zero-span mode at the given center frequency, RBW and sweep time. The trace then
+// shows the signal level over time instead of frequency, see GetZeroSpanData.
+func (d *Device) SetZeroSpan(zs ZeroSpan) error {
+	return d.SetZeroSpanContext(context.Background(), zs)
+}
+
+// SetZeroSpanContext is like SetZeroSpan but uses ctx to cancel the command.
+func (d *Device) SetZeroSpanContext(ctx context.Context, zs ZeroSpan) error {
+	d.logger.Info("setting zero span", "settings", zs)
+
+	if err := d.SetSweepContinuousWaveContext(ctx, zs.Frequency); err != nil {
+		return err
+	}
+
+	if zs.RBW == 0 {
+		if err := d.SetRBWAutoContext(ctx); err != nil {
+			return err
+		}
+	} else if err := d.SetRBWContext(ctx, zs.RBW); err != nil {
+		return err
+	}
+
+	return d.SetSweepTimeContext(ctx, zs.SweepTime)
+}
+
+// GetZeroSpanData returns the values of the given trace as time series. The time offsets are spread evenly over
+// the sweep time reported by the device, so the data is only meaningful in zero-span mode (see SetZeroSpan).
+func (d *Device) GetZeroSpanData(traceID uint) ([]TimeSample, error) {
+	return d.GetZeroSpanDataContext(context.Background(), traceID)
+}
+
+// GetZeroSpanDataContext is like GetZeroSpanData but uses ctx to cancel the command.
+func (d *Device) GetZeroSpanDataContext(ctx context.Context, traceID uint) ([]TimeSample, error) {
+	d.logger.Info("getting zero span data", "trace_id", traceID)
+
+	sweepTime, err := d.GetSweepTimeContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := d.GetTraceValuesContext(ctx, traceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no trace values for trace %d", traceID)
+	}
+
+	samples := make([]TimeSample, len(values))
+	for i, v := range values {
+		var offset time.Duration
+		if len(values) > 1 {
+			offset = sweepTime * time.Duration(i) / time.Duration(len(values)-1)
+		}
+		samples[i] = TimeSample{
+			Trace:  v.Trace,
+			Point:  v.Point,
+			Offset: offset,
+			Value:  v.Value,
+		}
+	}
+
+	return samples, nil
+}
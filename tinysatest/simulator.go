@@ -0,0 +1,1029 @@
+// Package tinysatest provides an in-process tinySA firmware simulator for testing.
+//
+// A Simulator emulates the tinySA USB shell closely enough to drive a tinysa.Device end to end without hardware: it
+// echoes every command followed by `\r\n`, writes the response and finishes with the `ch> ` prompt. It implements
+// the tinysa.Transport interface and can be passed to tinysa.NewDeviceFromTransport:
+//
+//	sim := tinysatest.New(tinysatest.ModelUltra)
+//	dev, err := tinysa.NewDeviceFromTransport(sim)
+package tinysatest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Model selects the emulated tinySA model. The values match tinysa.ModelBasic and tinysa.ModelUltra.
+type Model string
+
+// Supported simulator models.
+const (
+	ModelBasic Model = "tinySA"
+	ModelUltra Model = "tinySA4"
+)
+
+const (
+	// prompt is the shell prompt written after every response.
+	prompt = "ch> "
+
+	// terminator is the line terminator used by the firmware.
+	terminator = "\r\n"
+)
+
+// ErrClosed is returned by Read and Write after the simulator has been closed.
+var ErrClosed = errors.New("simulator closed")
+
+// HandlerFunc produces the raw response for a command, without echo and prompt. The args slice contains the
+// whitespace separated arguments following the command name. String responses must terminate every line with
+// `\r\n`. Handlers are called with the simulator lock held and must not call methods of the Simulator.
+type HandlerFunc func(args []string) []byte
+
+// modelConfig holds the model specific defaults of the simulator.
+type modelConfig struct {
+	version     string
+	hwVersion   string
+	width       int
+	height      int
+	start       uint64
+	stop        uint64
+	maxPoints   uint
+	traces      int
+	levelOffset float64
+	rbwRange    string
+	rbwSteps    []string
+	sdCard      bool
+}
+
+// modelConfigs maps the simulator models to their defaults.
+var modelConfigs = map[Model]modelConfig{
+	ModelBasic: {
+		version:     "1.4-175-g1419ca3",
+		hwVersion:   "0.3.1",
+		width:       320,
+		height:      280,
+		stop:        350000000,
+		maxPoints:   290,
+		traces:      3,
+		levelOffset: 128,
+		rbwRange:    "3..600",
+		rbwSteps:    []string{"3", "10", "30", "100", "300", "600"},
+	},
+	ModelUltra: {
+		version:     "1.4-197-gaa78ccc",
+		hwVersion:   "0.4.5.1",
+		width:       480,
+		height:      320,
+		stop:        800000000,
+		maxPoints:   450,
+		traces:      4,
+		levelOffset: 174,
+		rbwRange:    "0.2..850",
+		rbwSteps:    []string{"0.2", "1", "3", "10", "30", "100", "300", "600", "850"},
+		sdCard:      true,
+	},
+}
+
+// marker holds the state of a single marker.
+type marker struct {
+	enabled  bool
+	trace    int
+	index    uint
+	tracking bool
+	delta    int
+}
+
+// trace holds the state of a single trace.
+type trace struct {
+	enabled bool
+	calc    string
+}
+
+// Simulator emulates the shell of a tinySA device. It is safe for concurrent use.
+type Simulator struct {
+	mu          sync.Mutex
+	model       Model
+	cfg         modelConfig
+	readTimeout time.Duration
+	closed      bool
+	input       []byte
+	output      []byte
+	ready       chan struct{}
+	done        chan struct{}
+	handlers    map[string]HandlerFunc
+	commands    []string
+
+	// device state
+	start       uint64
+	stop        uint64
+	points      uint
+	sweepMode   string
+	sweepTime   float64
+	paused      bool
+	unit        string
+	refLevel    float64
+	scale       float64
+	traces      []trace
+	markers     []marker
+	deviceID    uint
+	vbat        uint
+	vbatOffset  uint
+	spur        string
+	lna         bool
+	rbw         string
+	attenuation string
+	mode        string
+	outputOn    bool
+	outputFreq  uint64
+	outputLevel float64
+	modulation  string
+	trigger     string
+	triggerEdge string
+	triggerLvl  float64
+	armed       bool
+	sdFiles     map[string][]byte
+	signalFreq  uint64
+	signalLevel float64
+}
+
+// New creates a new Simulator emulating the given model with its power-on defaults.
+func New(model Model) *Simulator {
+	cfg, ok := modelConfigs[model]
+	if !ok {
+		panic(fmt.Sprintf("tinysatest: unknown model %q", model))
+	}
+
+	s := &Simulator{
+		model:       model,
+		cfg:         cfg,
+		readTimeout: -1,
+		ready:       make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		start:       cfg.start,
+		stop:        cfg.stop,
+		points:      cfg.maxPoints,
+		sweepMode:   "normal",
+		unit:        "dBm",
+		refLevel:    -10,
+		scale:       10,
+		traces:      make([]trace, cfg.traces),
+		markers:     make([]marker, 4),
+		vbat:        4191,
+		vbatOffset:  300,
+		spur:        "auto",
+		rbw:         "auto",
+		attenuation: "auto",
+		mode:        "low input",
+		modulation:  "off",
+		trigger:     "auto",
+		triggerEdge: "up",
+		triggerLvl:  -40,
+		sdFiles:     make(map[string][]byte),
+		signalFreq:  (cfg.start + cfg.stop) / 2,
+		signalLevel: -30,
+	}
+	s.traces[0].enabled = true
+	for i := range s.markers {
+		s.markers[i].trace = 1
+	}
+	s.markers[0].enabled = true
+	s.markers[0].index = s.peakIndex()
+	s.handlers = s.builtinHandlers()
+	if cfg.sdCard {
+		s.handlers["sd_list"] = s.handleSDList
+		s.handlers["sd_read"] = s.handleSDRead
+		s.handlers["sd_delete"] = s.handleSDDelete
+	}
+
+	return s
+}
+
+// Handle overrides the response of the given command, e.g. to inject malformed or error responses.
+func (s *Simulator) Handle(command string, h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[command] = h
+}
+
+// SetSignal places a synthetic carrier with the given level in dBm at the given frequency in Hz.
+func (s *Simulator) SetSignal(freqHz uint64, levelDbm float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signalFreq = freqHz
+	s.signalLevel = levelDbm
+}
+
+// AddSDFile places a file with the given content on the simulated SD card, replacing an existing file of the same
+// name. The SD card commands are only available for models with SD card slot.
+func (s *Simulator) AddSDFile(name string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sdFiles[name] = slices.Clone(data)
+}
+
+// Commands returns all commands received so far, in order and without line terminator.
+func (s *Simulator) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.commands)
+}
+
+// Write feeds input to the simulated shell. Every complete line is executed as a command.
+func (s *Simulator) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, ErrClosed
+	}
+
+	s.input = append(s.input, p...)
+	for {
+		i := slices.Index(s.input, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(s.input[:i]), "\r")
+		s.input = s.input[i+1:]
+		s.execute(line)
+	}
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+
+	return len(p), nil
+}
+
+// Read reads pending shell output. It returns 0 and a nil error if no output is available within the read timeout.
+func (s *Simulator) Read(p []byte) (int, error) {
+	var timeout <-chan time.Time
+	if s.getReadTimeout() >= 0 {
+		timer := time.NewTimer(s.getReadTimeout())
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return 0, ErrClosed
+		}
+		if len(s.output) > 0 {
+			n := copy(p, s.output)
+			s.output = s.output[n:]
+			s.mu.Unlock()
+			return n, nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.ready:
+		case <-s.done:
+		case <-timeout:
+			return 0, nil
+		}
+	}
+}
+
+// SetReadTimeout sets the timeout for Read. A negative value disables the timeout.
+func (s *Simulator) SetReadTimeout(t time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readTimeout = t
+	return nil
+}
+
+// Close closes the simulator. Subsequent reads and writes return ErrClosed.
+func (s *Simulator) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+	return nil
+}
+
+// getReadTimeout returns the current read timeout.
+func (s *Simulator) getReadTimeout() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readTimeout
+}
+
+// execute runs a single command line and appends echo, response and prompt to the output.
+func (s *Simulator) execute(line string) {
+	s.output = append(s.output, line+terminator...)
+
+	fields := strings.Fields(line)
+	if len(fields) > 0 {
+		s.commands = append(s.commands, line)
+		if h, ok := s.handlers[fields[0]]; ok {
+			s.output = append(s.output, h(fields[1:])...)
+		} else {
+			s.output = append(s.output, lines(fields[0]+"?")...)
+		}
+	}
+
+	s.output = append(s.output, prompt...)
+}
+
+// builtinHandlers returns the default command handlers.
+func (s *Simulator) builtinHandlers() map[string]HandlerFunc {
+	return map[string]HandlerFunc{
+		"version":     s.handleVersion,
+		"sweep":       s.handleSweep,
+		"sweeptime":   s.handleSweepTime,
+		"frequencies": s.handleFrequencies,
+		"scanraw":     s.handleScanRaw,
+		"scan":        s.handleScan,
+		"trace":       s.handleTrace,
+		"marker":      s.handleMarker,
+		"calc":        s.handleCalc,
+		"vbat":        s.handleVbat,
+		"vbat_offset": s.handleVbatOffset,
+		"deviceid":    s.handleDeviceID,
+		"capture":     s.handleCapture,
+		"status":      s.handleStatus,
+		"pause":       s.handlePause,
+		"resume":      s.handleResume,
+		"spur":        s.handleSpur,
+		"lna":         s.handleLNA,
+		"rbw":         s.handleRBW,
+		"attenuate":   s.handleAttenuate,
+		"mode":        s.handleMode,
+		"freq":        s.handleFreq,
+		"level":       s.handleLevel,
+		"output":      s.handleOutput,
+		"modulation":  s.handleModulation,
+		"trigger":     s.handleTrigger,
+		"menu":        s.handleNoop,
+		"load":        s.handleNoop,
+		"save":        s.handleNoop,
+		"reset":       s.handleNoop,
+	}
+}
+
+// lines joins the given lines, terminating each one with `\r\n`.
+func lines(l ...string) []byte {
+	var b []byte
+	for _, line := range l {
+		b = append(b, line+terminator...)
+	}
+	return b
+}
+
+// parseFreq parses a frequency argument in Hz.
+func parseFreq(s string) (uint64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, false
+	}
+	return uint64(f), true
+}
+
+// frequency returns the frequency of the given point of a sweep.
+func frequency(start, stop uint64, points, point uint) uint64 {
+	if points < 2 {
+		return start
+	}
+	return start + (stop-start)*uint64(point)/uint64(points-1)
+}
+
+// frequency returns the frequency of the given sweep point.
+func (s *Simulator) frequency(point uint) uint64 {
+	return frequency(s.start, s.stop, s.points, point)
+}
+
+// levelAt returns the synthetic level in dBm at the given frequency for a bin of the given width: a noise floor with
+// a little deterministic ripple, plus the carrier set with SetSignal if it falls into the bin.
+func (s *Simulator) levelAt(freq uint64, bin uint64) float64 {
+	value := -100 + 2*math.Sin(float64(freq)/3e6)
+
+	diff := max(freq, s.signalFreq) - min(freq, s.signalFreq)
+	if diff <= max(bin, 1)/2 && s.signalLevel > value {
+		value = s.signalLevel
+	}
+
+	return math.Round(value*100) / 100
+}
+
+// level returns the synthetic level in dBm at the given sweep point.
+func (s *Simulator) level(point uint) float64 {
+	bin := uint64(0)
+	if s.points > 1 {
+		bin = (s.stop - s.start) / uint64(s.points-1)
+	}
+	return s.levelAt(s.frequency(point), bin)
+}
+
+// peakIndex returns the sweep point with the highest level.
+func (s *Simulator) peakIndex() uint {
+	peak := uint(0)
+	for i := range s.points {
+		if s.level(i) > s.level(peak) {
+			peak = i
+		}
+	}
+	return peak
+}
+
+func (s *Simulator) handleNoop(_ []string) []byte {
+	return nil
+}
+
+func (s *Simulator) handleVersion(_ []string) []byte {
+	return lines(fmt.Sprintf("%s_v%s", s.model, s.cfg.version), "HW Version:V"+s.cfg.hwVersion)
+}
+
+func (s *Simulator) handleSweep(args []string) []byte {
+	usage := lines(
+		"usage: sweep {start(Hz)} [stop(Hz)] [points]",
+		"\tsweep {start|stop|center|span|cw} {freq(Hz)}",
+		"\tsweep {normal|precise|fast|noise}")
+
+	if len(args) == 0 {
+		return lines(fmt.Sprintf("%d %d %d", s.start, s.stop, s.points))
+	}
+
+	switch args[0] {
+	case "normal", "precise", "fast", "noise":
+		s.sweepMode = args[0]
+		return nil
+	case "start", "stop", "center", "span", "cw":
+		if len(args) != 2 {
+			return usage
+		}
+		freq, ok := parseFreq(args[1])
+		if !ok {
+			return usage
+		}
+		center, span := (s.start+s.stop)/2, s.stop-s.start
+		switch args[0] {
+		case "start":
+			s.start = freq
+		case "stop":
+			s.stop = freq
+		case "center":
+			s.start, s.stop = freq-min(freq, span/2), freq+span/2
+		case "span":
+			s.start, s.stop = center-min(center, freq/2), center+freq/2
+		case "cw":
+			s.start, s.stop = freq, freq
+		}
+		return nil
+	}
+
+	if len(args) > 3 {
+		return usage
+	}
+	start, ok := parseFreq(args[0])
+	if !ok {
+		return usage
+	}
+	stop := s.stop
+	if len(args) > 1 {
+		if stop, ok = parseFreq(args[1]); !ok {
+			return usage
+		}
+	}
+	points := s.points
+	if len(args) > 2 {
+		p, err := strconv.ParseUint(args[2], 10, 0)
+		if err != nil || p < 2 || uint(p) > s.cfg.maxPoints {
+			return usage
+		}
+		points = uint(p)
+	}
+	s.start, s.stop, s.points = start, stop, points
+
+	return nil
+}
+
+// handleSweepTime sets the sweep time in seconds, or with a `m` or `u` suffix in milli- or microseconds. Without
+// arguments, it prints the usage and the current sweep time in seconds.
+func (s *Simulator) handleSweepTime(args []string) []byte {
+	usage := "usage: sweeptime 0.003..60"
+	if len(args) == 0 {
+		return lines(usage, strconv.FormatFloat(s.sweepTime, 'f', -1, 64)+"s")
+	}
+	if len(args) != 1 {
+		return lines(usage)
+	}
+
+	value, divisor := args[0], 1.0
+	switch {
+	case strings.HasSuffix(value, "u"):
+		value, divisor = strings.TrimSuffix(value, "u"), 1e6
+	case strings.HasSuffix(value, "m"):
+		value, divisor = strings.TrimSuffix(value, "m"), 1e3
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return lines(usage)
+	}
+	s.sweepTime = seconds / divisor
+	return nil
+}
+
+func (s *Simulator) handleFrequencies(_ []string) []byte {
+	var b []byte
+	for i := range s.points {
+		b = append(b, fmt.Sprintf("%d%s", s.frequency(i), terminator)...)
+	}
+	return b
+}
+
+// handleScanRaw scans without changing the sweep settings and returns the levels framed by curly braces, each value
+// as `x` followed by the little-endian uint16 of (level + offset) * 32.
+func (s *Simulator) handleScanRaw(args []string) []byte {
+	usage := lines("usage: scanraw {start(Hz)} {stop(Hz)} [points] [option]")
+	if len(args) < 2 || len(args) > 4 {
+		return usage
+	}
+	start, ok1 := parseFreq(args[0])
+	stop, ok2 := parseFreq(args[1])
+	if !ok1 || !ok2 || start > stop {
+		return usage
+	}
+	points := s.points
+	if len(args) > 2 {
+		p, err := strconv.ParseUint(args[2], 10, 0)
+		if err != nil || p < 1 {
+			return usage
+		}
+		points = uint(p)
+	}
+
+	bin := uint64(0)
+	if points > 1 {
+		bin = (stop - start) / uint64(points-1)
+	}
+
+	b := []byte{'{'}
+	for i := range points {
+		raw := uint16((s.levelAt(frequency(start, stop, points, i), bin) + s.cfg.levelOffset) * 32) // #nosec G115
+		b = append(b, 'x', byte(raw), byte(raw>>8))
+	}
+	return append(b, '}')
+}
+
+// handleScan scans without changing the sweep settings and prints the columns selected by outmask: 1 frequency,
+// 2 measured level, 4 stored trace (a flat -100 dBm).
+func (s *Simulator) handleScan(args []string) []byte {
+	usage := lines("usage: scan {start(Hz)} {stop(Hz)} [points] [outmask]")
+	if len(args) < 2 || len(args) > 4 {
+		return usage
+	}
+	start, ok1 := parseFreq(args[0])
+	stop, ok2 := parseFreq(args[1])
+	if !ok1 || !ok2 || start > stop {
+		return usage
+	}
+	points := s.points
+	if len(args) > 2 {
+		p, err := strconv.ParseUint(args[2], 10, 0)
+		if err != nil || p < 1 || uint(p) > s.cfg.maxPoints {
+			return usage
+		}
+		points = uint(p)
+	}
+	outmask := uint64(0)
+	if len(args) > 3 {
+		var err error
+		if outmask, err = strconv.ParseUint(args[3], 10, 0); err != nil {
+			return usage
+		}
+	}
+	if outmask == 0 {
+		return nil
+	}
+
+	bin := uint64(0)
+	if points > 1 {
+		bin = (stop - start) / uint64(points-1)
+	}
+
+	var b []byte
+	for i := range points {
+		freq := frequency(start, stop, points, i)
+		if outmask&1 != 0 {
+			b = append(b, fmt.Sprintf("%d ", freq)...)
+		}
+		if outmask&2 != 0 {
+			b = append(b, fmt.Sprintf("%.2e ", s.levelAt(freq, bin))...)
+		}
+		if outmask&4 != 0 {
+			b = append(b, fmt.Sprintf("%.2e ", -100.0)...)
+		}
+		b = append(b, terminator...)
+	}
+	return b
+}
+
+func (s *Simulator) handleTrace(args []string) []byte {
+	usage := lines(
+		"usage: trace {dBm|dBmV|dBuV|RAW|V|Vpp|W}",
+		"\ttrace {scale|reflevel} auto|{value}",
+		"\ttrace [{trace#}] value",
+		"\ttrace [{trace#}] {view} on|off")
+
+	formatTrace := func(t int) string {
+		return fmt.Sprintf("%d: %s %.9f %.9f", t, s.unit, s.refLevel, s.scale)
+	}
+
+	if len(args) == 0 {
+		var l []string
+		for i, t := range s.traces {
+			if t.enabled {
+				l = append(l, formatTrace(i+1))
+			}
+		}
+		return lines(l...)
+	}
+
+	switch args[0] {
+	case "RAW", "dBm", "dBmV", "dBuV", "V", "Vpp", "W":
+		s.unit = args[0]
+		return nil
+	case "reflevel", "scale":
+		if len(args) != 2 {
+			return usage
+		}
+		if args[1] == "auto" {
+			return nil
+		}
+		v, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return usage
+		}
+		if args[0] == "reflevel" {
+			s.refLevel = v
+		} else {
+			s.scale = v
+		}
+		return nil
+	}
+
+	t, err := strconv.Atoi(args[0])
+	if err != nil || t < 1 || t > len(s.traces) {
+		return usage
+	}
+
+	if len(args) == 1 {
+		return lines(formatTrace(t))
+	}
+
+	switch {
+	case len(args) == 2 && args[1] == "value":
+		var b []byte
+		for i := range s.points {
+			b = append(b, fmt.Sprintf("trace %d value %d %.2f%s", t, i, s.level(i), terminator)...)
+		}
+		return b
+	case len(args) == 3 && args[1] == "view" && (args[2] == "on" || args[2] == "off"):
+		s.traces[t-1].enabled = args[2] == "on"
+		return nil
+	}
+
+	return usage
+}
+
+func (s *Simulator) handleMarker(args []string) []byte {
+	usage := lines(
+		"usage: marker [n] [on|off|peak|{freq}|{index}]",
+		"\tmarker [n] {trace|delta} {n}",
+		"\tmarker [n] tracking on|off")
+
+	formatMarker := func(m int) string {
+		idx := s.markers[m-1].index
+		return fmt.Sprintf("%d %d %d %.2e", m, idx, s.frequency(idx), s.level(idx))
+	}
+
+	if len(args) == 0 {
+		var l []string
+		for i, m := range s.markers {
+			if m.enabled {
+				l = append(l, formatMarker(i+1))
+			}
+		}
+		return lines(l...)
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(s.markers) {
+		return usage
+	}
+	m := &s.markers[n-1]
+
+	if len(args) == 1 {
+		return lines(formatMarker(n))
+	}
+
+	switch {
+	case len(args) == 2 && args[1] == "on":
+		m.enabled = true
+	case len(args) == 2 && args[1] == "off":
+		m.enabled = false
+	case len(args) == 2 && args[1] == "peak":
+		m.enabled = true
+		m.index = s.peakIndex()
+	case len(args) == 3 && args[1] == "trace":
+		t, err := strconv.Atoi(args[2])
+		if err != nil || t < 1 || t > len(s.traces) {
+			return usage
+		}
+		m.trace = t
+	case len(args) == 3 && args[1] == "delta":
+		if args[2] == "off" {
+			m.delta = 0
+			return nil
+		}
+		ref, err := strconv.Atoi(args[2])
+		if err != nil || ref < 1 || ref > len(s.markers) {
+			return usage
+		}
+		m.delta = ref
+	case len(args) == 3 && args[1] == "tracking" && (args[2] == "on" || args[2] == "off"):
+		m.tracking = args[2] == "on"
+	case len(args) == 2:
+		freq, ok := parseFreq(args[1])
+		if !ok || freq < s.start || freq > s.stop {
+			return usage
+		}
+		m.enabled = true
+		m.index = 0
+		if s.stop > s.start {
+			m.index = uint((freq - s.start) * uint64(s.points-1) / (s.stop - s.start))
+		}
+	default:
+		return usage
+	}
+
+	return nil
+}
+
+func (s *Simulator) handleCalc(args []string) []byte {
+	usage := lines("usage: calc [n] off|minh|maxh|maxd|aver4|aver16|quasi")
+	if len(args) != 2 {
+		return usage
+	}
+	t, err := strconv.Atoi(args[0])
+	if err != nil || t < 1 || t > len(s.traces) {
+		return usage
+	}
+	switch args[1] {
+	case "off", "minh", "maxh", "maxd", "aver4", "aver16", "quasi":
+		s.traces[t-1].calc = args[1]
+		return nil
+	}
+	return usage
+}
+
+func (s *Simulator) handleVbat(_ []string) []byte {
+	return lines(fmt.Sprintf("%d mV", s.vbat))
+}
+
+func (s *Simulator) handleVbatOffset(args []string) []byte {
+	if len(args) == 0 {
+		return lines(strconv.FormatUint(uint64(s.vbatOffset), 10))
+	}
+	v, err := strconv.ParseUint(args[0], 10, 0)
+	if err != nil {
+		return lines("usage: vbat_offset [{0..4095}]")
+	}
+	s.vbatOffset = uint(v)
+	return nil
+}
+
+func (s *Simulator) handleDeviceID(args []string) []byte {
+	if len(args) == 0 {
+		return lines(fmt.Sprintf("deviceid %d", s.deviceID))
+	}
+	id, err := strconv.ParseUint(args[0], 10, 0)
+	if err != nil {
+		return lines("usage: deviceid [{number}]")
+	}
+	s.deviceID = uint(id)
+	return nil
+}
+
+// handleCapture returns an RGB565 (big-endian) frame with the screen size of the model, filled with a horizontal
+// color gradient.
+func (s *Simulator) handleCapture(_ []string) []byte {
+	b := make([]byte, 0, s.cfg.width*s.cfg.height*2)
+	for range s.cfg.height {
+		for x := range s.cfg.width {
+			pixel := uint16(x * 0x1F / s.cfg.width) // #nosec G115
+			b = append(b, byte(pixel>>8), byte(pixel))
+		}
+	}
+	return b
+}
+
+// handleStatus reports whether the sweep is paused. An armed single trigger fires once the synthetic carrier crosses
+// the trigger level in the configured direction, which completes the sweep and pauses it.
+func (s *Simulator) handleStatus(_ []string) []byte {
+	if s.armed && (s.signalLevel >= s.triggerLvl) == (s.triggerEdge == "up") {
+		s.armed = false
+		s.paused = true
+	}
+	if s.paused {
+		return lines("Paused")
+	}
+	return lines("Resumed")
+}
+
+func (s *Simulator) handlePause(_ []string) []byte {
+	s.paused = true
+	return nil
+}
+
+func (s *Simulator) handleResume(_ []string) []byte {
+	s.paused = false
+	return nil
+}
+
+func (s *Simulator) handleSpur(args []string) []byte {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off" && args[0] != "auto") {
+		return lines("usage: spur on|off|auto")
+	}
+	s.spur = args[0]
+	return nil
+}
+
+func (s *Simulator) handleLNA(args []string) []byte {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		return lines("usage: lna on|off")
+	}
+	s.lna = args[0] == "on"
+	return nil
+}
+
+// handleRBW sets the resolution bandwidth in kHz. Without arguments, it prints the usage and the current setting.
+func (s *Simulator) handleRBW(args []string) []byte {
+	usage := "usage: rbw " + s.cfg.rbwRange + "|auto"
+	if len(args) == 0 {
+		if s.rbw == "auto" {
+			return lines(usage, "auto")
+		}
+		return lines(usage, s.rbw+"kHz")
+	}
+	if len(args) != 1 || (args[0] != "auto" && !slices.Contains(s.cfg.rbwSteps, args[0])) {
+		return lines(usage)
+	}
+	s.rbw = args[0]
+	return nil
+}
+
+// handleAttenuate sets the input attenuation in dB. Without arguments, it prints the usage and the current setting.
+func (s *Simulator) handleAttenuate(args []string) []byte {
+	usage := "usage: attenuate 0..31|auto"
+	if len(args) == 0 {
+		if s.attenuation == "auto" {
+			return lines(usage, "auto")
+		}
+		return lines(usage, s.attenuation+"dB")
+	}
+	if len(args) != 1 {
+		return lines(usage)
+	}
+	if args[0] != "auto" {
+		if v, err := strconv.ParseUint(args[0], 10, 0); err != nil || v > 31 {
+			return lines(usage)
+		}
+	}
+	s.attenuation = args[0]
+	return nil
+}
+
+// handleMode switches between input (analyzer) and output (generator) mode. Without arguments, it prints the usage
+// and the current mode.
+func (s *Simulator) handleMode(args []string) []byte {
+	usage := "usage: mode low|high input|output"
+	if len(args) == 0 {
+		return lines(usage, s.mode)
+	}
+	if len(args) != 2 || (args[0] != "low" && args[0] != "high") || (args[1] != "input" && args[1] != "output") {
+		return lines(usage)
+	}
+	s.mode = args[0] + " " + args[1]
+	if args[1] == "input" {
+		s.outputOn = false
+	}
+	return nil
+}
+
+func (s *Simulator) handleFreq(args []string) []byte {
+	if len(args) != 1 {
+		return lines("usage: freq {frequency(Hz)}")
+	}
+	freq, ok := parseFreq(args[0])
+	if !ok {
+		return lines("usage: freq {frequency(Hz)}")
+	}
+	s.outputFreq = freq
+	return nil
+}
+
+func (s *Simulator) handleLevel(args []string) []byte {
+	if len(args) != 1 {
+		return lines("usage: level -76..13")
+	}
+	level, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return lines("usage: level -76..13")
+	}
+	s.outputLevel = level
+	return nil
+}
+
+func (s *Simulator) handleOutput(args []string) []byte {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		return lines("usage: output on|off")
+	}
+	s.outputOn = args[0] == "on"
+	return nil
+}
+
+func (s *Simulator) handleModulation(args []string) []byte {
+	usage := "usage: modulation off|AM|NFM|WFM|extern|freq"
+	switch {
+	case len(args) == 1 && slices.Contains([]string{"off", "AM", "NFM", "WFM", "extern"}, args[0]):
+		s.modulation = args[0]
+	case len(args) == 2 && args[0] == "freq":
+		if _, ok := parseFreq(args[1]); !ok {
+			return lines(usage)
+		}
+	default:
+		return lines(usage)
+	}
+	return nil
+}
+
+// handleTrigger sets the trigger mode, edge or level. Arming a single trigger resumes the sweep until it fires.
+func (s *Simulator) handleTrigger(args []string) []byte {
+	if len(args) != 1 {
+		return lines("usage: trigger auto|normal|single|up|down|{level(dBm)}")
+	}
+	switch args[0] {
+	case "auto", "normal":
+		s.trigger = args[0]
+		s.armed = false
+	case "single":
+		s.trigger = args[0]
+		s.armed = true
+		s.paused = false
+	case "up", "down":
+		s.triggerEdge = args[0]
+	default:
+		level, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return lines("usage: trigger auto|normal|single|up|down|{level(dBm)}")
+		}
+		s.triggerLvl = level
+	}
+	return nil
+}
+
+// handleSDList prints the name and size of every file on the SD card, sorted by name.
+func (s *Simulator) handleSDList(_ []string) []byte {
+	var out []string
+	for _, name := range slices.Sorted(maps.Keys(s.sdFiles)) {
+		out = append(out, fmt.Sprintf("%s %d", name, len(s.sdFiles[name])))
+	}
+	return lines(out...)
+}
+
+// handleSDRead writes the 32-bit little-endian file size followed by the file content, without line terminator.
+func (s *Simulator) handleSDRead(args []string) []byte {
+	if len(args) != 1 {
+		return lines("usage: sd_read {filename}")
+	}
+	data, ok := s.sdFiles[args[0]]
+	if !ok {
+		return lines("err: no file")
+	}
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(data))), data...) // #nosec G115
+}
+
+func (s *Simulator) handleSDDelete(args []string) []byte {
+	if len(args) != 1 {
+		return lines("usage: sd_delete {filename}")
+	}
+	if _, ok := s.sdFiles[args[0]]; !ok {
+		return lines("delete: " + args[0] + " err")
+	}
+	delete(s.sdFiles, args[0])
+	return lines("delete: " + args[0] + " OK")
+}
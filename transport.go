@@ -0,0 +1,64 @@
+package tinysa
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// Transport is the byte stream used to talk to a tinySA. A go.bug.st/serial.Port satisfies this interface, but any
+// other stream (TCP serial bridge, pipe, in-memory fake) can be used with NewDeviceFromTransport.
+//
+// Read must follow the semantics of serial.Port: when the read timeout expires without any data, it returns 0 and a
+// nil error instead of blocking forever.
+type Transport interface {
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	Close() error
+	SetReadTimeout(t time.Duration) error
+}
+
+// netTransport adapts a net.Conn to the Transport interface.
+type netTransport struct {
+	conn        net.Conn
+	readTimeout time.Duration
+}
+
+// NewNetTransport wraps a net.Conn (e.g. a TCP connection to a ser2net bridge) as Transport.
+func NewNetTransport(conn net.Conn) Transport {
+	return &netTransport{conn: conn}
+}
+
+// Read reads from the connection, returning 0 and a nil error if the read timeout expires.
+func (t *netTransport) Read(p []byte) (int, error) {
+	deadline := time.Time{}
+	if t.readTimeout > 0 {
+		deadline = time.Now().Add(t.readTimeout)
+	}
+	if err := t.conn.SetReadDeadline(deadline); err != nil {
+		return 0, err
+	}
+
+	n, err := t.conn.Read(p)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return n, nil
+	}
+	return n, err
+}
+
+// Write writes to the connection.
+func (t *netTransport) Write(p []byte) (int, error) {
+	return t.conn.Write(p)
+}
+
+// Close closes the connection.
+func (t *netTransport) Close() error {
+	return t.conn.Close()
+}
+
+// SetReadTimeout sets the timeout for each Read call. A negative or zero value disables the timeout.
+func (t *netTransport) SetReadTimeout(timeout time.Duration) error {
+	t.readTimeout = timeout
+	return nil
+}
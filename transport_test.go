@@ -0,0 +1,67 @@
+package tinysa
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveVersion answers `version` commands on conn like a tinySA Ultra until the connection is closed.
+func serveVersion(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		response := cmd + "\r\n"
+		if cmd == "version" {
+			response += "tinySA4_v1.4-197-gaa78ccc\r\nHW Version:V0.4.5.1\r\n"
+		}
+		if _, err = conn.Write([]byte(response + "ch> ")); err != nil {
+			return
+		}
+	}
+}
+
+func TestNewDeviceFromNetTransport(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go serveVersion(server)
+
+	dev, err := NewDeviceFromTransport(NewNetTransport(client), WithReadTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	defer dev.Close()
+
+	if dev.Model() != ModelUltra {
+		t.Errorf("expected model %s, got %s", ModelUltra, dev.Model())
+	}
+	if dev.Version() != "1.4-197-gaa78ccc" {
+		t.Errorf("expected version %q, got %q", "1.4-197-gaa78ccc", dev.Version())
+	}
+	if dev.HardwareVersion() != "0.4.5.1" {
+		t.Errorf("expected hardware version %q, got %q", "0.4.5.1", dev.HardwareVersion())
+	}
+}
+
+func TestNetTransportReadTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	transport := NewNetTransport(client)
+	defer transport.Close()
+
+	if err := transport.SetReadTimeout(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := transport.Read(make([]byte, 16))
+	if n != 0 || err != nil {
+		t.Fatalf("expected (0, nil) on read timeout, got (%d, %v)", n, err)
+	}
+}
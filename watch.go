@@ -0,0 +1,174 @@
+package tinysa
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// watchPollInterval is the time between two scans of the serial ports by Watch. It is a variable so tests can
+// shorten it.
+var watchPollInterval = time.Second
+
+// watchBuffer is the number of events buffered in the channel returned by Watch.
+const watchBuffer = 16
+
+// DeviceEventType is the type of a DeviceEvent.
+type DeviceEventType int
+
+const (
+	// DeviceAttached is emitted when a tinySA appears on a serial port.
+	DeviceAttached DeviceEventType = iota
+
+	// DeviceDetached is emitted when the serial port of an attached tinySA disappears.
+	DeviceDetached
+)
+
+// String returns the name of the event type.
+func (t DeviceEventType) String() string {
+	switch t {
+	case DeviceAttached:
+		return "attached"
+	case DeviceDetached:
+		return "detached"
+	default:
+		return fmt.Sprintf("DeviceEventType(%d)", int(t))
+	}
+}
+
+// DeviceEvent reports a tinySA attached to or detached from a serial port.
+type DeviceEvent struct {
+	Type            DeviceEventType
+	Port            PortInfo // Serial port of the device
+	Model           Model    // Device model
+	Version         string   // Firmware version
+	HardwareVersion string   // Hardware version
+}
+
+// Watch polls the serial ports and emits a DeviceAttached event for every port a tinySA appears on, and a
+// DeviceDetached event when the port disappears again. Devices already connected when Watch is called are reported
+// as attached by the first scan. Only ports with the USB ids of a tinySA are probed, unless the platform reports no USB
+// details. New ports are probed with the `version` command and closed again, so the device can be opened with
+// NewDevice after the event; ports that cannot be opened or do not answer like a tinySA, e.g. because they are in use
+// or the device is still booting, are probed again by the next scan. The channel is closed after ctx is done, which
+// also cancels a running probe.
+func Watch(ctx context.Context, opts ...DeviceOption) <-chan DeviceEvent {
+	options := defaultDeviceOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.logger == nil {
+		options.logger = newNoopLogger()
+	}
+
+	options.logger.Debug("watching serial ports", "interval", watchPollInterval)
+
+	events := make(chan DeviceEvent, watchBuffer)
+	go watchPorts(ctx, options, events)
+	return events
+}
+
+// watchPorts scans the serial ports every watchPollInterval and sends events until ctx is done.
+func watchPorts(ctx context.Context, options deviceOptions, events chan<- DeviceEvent) {
+	defer close(events)
+
+	logger := options.logger
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	// known holds the attach event of each port with a tinySA
+	known := make(map[string]DeviceEvent)
+
+	send := func(ev DeviceEvent) bool {
+		logger.Info("device event", "type", ev.Type, "port", ev.Port.Name, "model", ev.Model)
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		ports, err := listPorts()
+		if err != nil {
+			logger.Warn("failed to list serial ports", "err", err)
+		} else {
+			present := make(map[string]bool, len(ports))
+			for _, p := range ports {
+				present[p.Name] = true
+			}
+
+			for _, p := range candidatePorts(ports) {
+				if _, ok := known[p.Name]; ok {
+					continue
+				}
+
+				ev, err := probePort(ctx, p, options)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					logger.Debug("no device on port, retrying next scan", "port", p.Name, "err", err)
+					continue
+				}
+				known[p.Name] = ev
+				if !send(ev) {
+					return
+				}
+			}
+
+			for _, name := range slices.Sorted(maps.Keys(known)) {
+				if present[name] {
+					continue
+				}
+				detached := known[name]
+				detached.Type = DeviceDetached
+				delete(known, name)
+				if !send(detached) {
+					return
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probePort opens the port and probes it for a tinySA. It returns an error if the port cannot be opened or does not
+// respond like a tinySA of a known model. ctx cancels the probe.
+func probePort(ctx context.Context, p PortInfo, options deviceOptions) (DeviceEvent, error) {
+	port, err := openPort(p.Name, options.baudrate)
+	if err != nil {
+		return DeviceEvent{}, err
+	}
+	defer func() { _ = port.Close() }()
+
+	if err = port.SetReadTimeout(options.readTimeout); err != nil {
+		return DeviceEvent{}, err
+	}
+
+	pr, err := probeDevice(ctx, options.logger, port, options.responseTimeout)
+	if err != nil {
+		return DeviceEvent{}, err
+	}
+	cfg, ok := deviceModels[pr.model]
+	if !ok {
+		return DeviceEvent{}, fmt.Errorf("unknown model %s", pr.model)
+	}
+
+	return DeviceEvent{
+		Type:            DeviceAttached,
+		Port:            p,
+		Model:           cfg.model,
+		Version:         pr.version,
+		HardwareVersion: pr.hwVersion,
+	}, nil
+}
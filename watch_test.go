@@ -0,0 +1,107 @@
+package tinysa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kkettinger/go-tinysa/tinysatest"
+)
+
+// nextEvent returns the next event of the channel, failing the test if none arrives in time.
+func nextEvent(t *testing.T, events <-chan DeviceEvent) DeviceEvent {
+	t.Helper()
+
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("event channel closed")
+		}
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+	return DeviceEvent{}
+}
+
+func TestWatch(t *testing.T) {
+	orig := watchPollInterval
+	watchPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { watchPollInterval = orig })
+
+	ports := newFakePorts(t)
+	ports.attach("/dev/ttyACM0", "400", tinysatest.ModelBasic, 1)
+	ports.ports = append(ports.ports, PortInfo{Name: "/dev/ttyS0"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := Watch(ctx, WithReadTimeout(5*time.Millisecond), WithResponseTimeout(20*time.Millisecond))
+
+	ev := nextEvent(t, events)
+	if ev.Type != DeviceAttached || ev.Port.Name != "/dev/ttyACM0" || ev.Model != ModelBasic {
+		t.Errorf("first event = %+v, want basic attached on /dev/ttyACM0", ev)
+	}
+	if ev.Version == "" || ev.HardwareVersion == "" {
+		t.Errorf("first event = %+v, want firmware and hardware version", ev)
+	}
+
+	ports.attach("/dev/ttyACM1", "401", tinysatest.ModelUltra, 2)
+	ev = nextEvent(t, events)
+	if ev.Type != DeviceAttached || ev.Port.Name != "/dev/ttyACM1" || ev.Model != ModelUltra {
+		t.Errorf("second event = %+v, want ultra attached on /dev/ttyACM1", ev)
+	}
+
+	ports.detach("/dev/ttyACM0")
+	ev = nextEvent(t, events)
+	if ev.Type != DeviceDetached || ev.Port.Name != "/dev/ttyACM0" || ev.Model != ModelBasic {
+		t.Errorf("third event = %+v, want basic detached from /dev/ttyACM0", ev)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchRetriesBootingDevice(t *testing.T) {
+	orig := watchPollInterval
+	watchPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { watchPollInterval = orig })
+
+	ports := newFakePorts(t)
+	ports.attachSilent("/dev/ttyACM0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := Watch(ctx, WithReadTimeout(5*time.Millisecond), WithResponseTimeout(20*time.Millisecond))
+
+	// Let the first probes fail, then finish booting.
+	time.Sleep(100 * time.Millisecond)
+	ports.mu.Lock()
+	delete(ports.silent, "/dev/ttyACM0")
+	ports.sims["/dev/ttyACM0"] = tinysatest.New(tinysatest.ModelUltra)
+	ports.mu.Unlock()
+
+	ev := nextEvent(t, events)
+	if ev.Type != DeviceAttached || ev.Port.Name != "/dev/ttyACM0" || ev.Model != ModelUltra {
+		t.Errorf("event = %+v, want ultra attached on /dev/ttyACM0", ev)
+	}
+}
+
+func TestWatchCancelDuringProbe(t *testing.T) {
+	ports := newFakePorts(t)
+	ports.attachSilent("/dev/ttyACM0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := Watch(ctx, WithReadTimeout(5*time.Millisecond), WithResponseTimeout(200*time.Millisecond))
+
+	// Without cancelling the probe, the silent port blocks the scan for several response timeouts.
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	begin := time.Now()
+	for range events {
+	}
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Errorf("event channel closed after %s, want shortly after cancel", elapsed)
+	}
+}